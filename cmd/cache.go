@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache of the parsed files",
+}
+
+// cacheCleanCmd represents the cache clean command
+var cacheCleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove the cache of the parsed files",
+	Run: func(cmd *cobra.Command, args []string) {
+		c, _, err := effectiveConfig(cmd.Flags())
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+		if err := c.CleanCache(); err != nil {
+			log.Fatalf("error: %v", err)
+		}
+	},
+}
+
+func init() {
+	addParseFlags(cacheCleanCmd.Flags())
+	cacheCmd.AddCommand(cacheCleanCmd)
+	RootCmd.AddCommand(cacheCmd)
+}
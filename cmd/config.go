@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show the configuration of the parser",
+}
+
+// configPrintCmd represents the config print command
+var configPrintCmd = &cobra.Command{
+	Use:   "print",
+	Short: "Print the configuration of the file overridden by the flags",
+	Run: func(cmd *cobra.Command, args []string) {
+		c, file, err := effectiveConfig(cmd.Flags())
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+		c = outputConfig(cmd.Flags(), c)
+
+		d, err := yaml.Marshal(c)
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+		if file != "" {
+			fmt.Printf("# %s\n", file)
+		}
+		fmt.Print(string(d))
+	},
+}
+
+func init() {
+	addParseFlags(configPrintCmd.Flags())
+	addOutputFlags(configPrintCmd.Flags())
+	configCmd.AddCommand(configPrintCmd)
+	RootCmd.AddCommand(configCmd)
+}
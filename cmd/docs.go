@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"log"
+	"strings"
+
+	"github.com/alexjomin/openapi-parser/docparser"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var (
+	docsFormat string
+	docsOutput string
+	docsSpec   string
+)
+
+// docsCmd represents the docs command
+var docsCmd = &cobra.Command{
+	Use:   "docs",
+	Short: "Render the documentation of the document in html or markdown",
+	Long: `Generate the document, or load an existing file with --spec, and render its
+documentation in a self-contained html page or a markdown file. The operations
+are grouped by tag and x-tagGroups.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, _, err := effectiveConfig(cmd.Flags())
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+
+		spec := docparser.NewOpenAPI()
+		if docsSpec != "" {
+			d, err := ioutil.ReadFile(docsSpec)
+			if err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			if err := yaml.Unmarshal(d, &spec); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			if c.FillExamples {
+				if err := spec.FillExamples(); err != nil {
+					log.Fatalf("error: %v", err)
+				}
+			}
+		} else {
+			if err := spec.Configure(c); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			if err := spec.Parse(c.Paths, c.Vendors, c.VendorsPath, c.ExitError); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+		}
+
+		d, err := spec.Docs(docsFormat)
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+		output := docsOutput
+		if output == "" {
+			output = "openapi.html"
+			if docsFormat == "markdown" {
+				output = "openapi.md"
+			}
+		}
+		if err := writeOutput(output, d); err != nil {
+			log.Fatalf("error: %v", err)
+		}
+	},
+}
+
+func init() {
+	addParseFlags(docsCmd.Flags())
+	docsCmd.Flags().StringVar(&docsFormat, "format", "html", "The format of the documentation, "+strings.Join(docparser.DocsFormats, " or "))
+	docsCmd.Flags().StringVar(&docsOutput, "output", "", "The output file, by default openapi.html or openapi.md")
+	docsCmd.Flags().StringVar(&docsSpec, "spec", "", "An existing document to render rather than generating it")
+	RootCmd.AddCommand(docsCmd)
+}
@@ -3,7 +3,6 @@ package cmd
 import (
 	"io/ioutil"
 	"log"
-	"reflect"
 	"strings"
 
 	"github.com/alexjomin/openapi-parser/docparser"
@@ -58,37 +57,11 @@ var mergeCmd = &cobra.Command{
 				logrus.Fatal(err)
 			}
 
-			for k, v := range spec.Paths {
-				url := k
-				for verb, action := range v {
-					logrus.WithField("verb", verb).WithField("url", url).Info("Adding Path")
-					main.AddOperation(url, verb, action)
-				}
-			}
-
-			for k, v := range spec.Components.Schemas {
-				s, ok := main.Components.Schemas[k]
-				if ok {
-					result := reflect.DeepEqual(s, v)
-					if !result {
-						logrus.
-							WithField("schema", k).
-							WithField("file", lf.Name()).
-							Fatal("Schema already exists and different !")
-					}
-					continue
-				}
-				main.Components.Schemas[k] = v
-				logrus.WithField("schema", k).Info("Adding Schema")
-			}
-
-			registeredServers := make(map[string]bool)
-			for _, server := range spec.Servers {
-				if _, found := registeredServers[server.URL]; found {
-					continue
-				}
-				main.Servers = append(main.Servers, server)
-				registeredServers[server.URL] = true
+			if err := main.Merge(spec); err != nil {
+				logrus.
+					WithError(err).
+					WithField("file", lf.Name()).
+					Fatal("Can't merge file")
 			}
 		}
 
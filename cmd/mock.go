@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/alexjomin/openapi-parser/docparser"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var (
+	mockAddr string
+	mockSpec string
+)
+
+// mockCmd represents the mock command
+var mockCmd = &cobra.Command{
+	Use:   "mock",
+	Short: "Serve a mock of the operations of the document",
+	Long: `Generate the document, or load an existing file with --spec, and serve every
+operation of the document. The requests are validated against the parameters
+and the request bodies, and the operations respond with their examples, or with
+values synthesised from their schemas. The response is chosen with the header
+Prefer: code=404, by default it's the first success response.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		spec := docparser.NewOpenAPI()
+		if mockSpec != "" {
+			d, err := ioutil.ReadFile(mockSpec)
+			if err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			if err := yaml.Unmarshal(d, &spec); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+		} else {
+			c, _, err := effectiveConfig(cmd.Flags())
+			if err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			if err := spec.Configure(c); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			if err := spec.Parse(c.Paths, c.Vendors, c.VendorsPath, c.ExitError); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+		}
+
+		handler, err := spec.Mock()
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+		ln, err := net.Listen("tcp", mockAddr)
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+		log.Printf("Serving the mock on http://%s", ln.Addr())
+		if err := http.Serve(ln, logRequests(handler)); err != nil {
+			log.Fatalf("error: %v", err)
+		}
+	},
+}
+
+// statusRecorder keeps the status code of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// logRequests logs the requests and the status codes of their responses
+func logRequests(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(rec, r)
+		log.Printf("%s %s %d", r.Method, r.URL.RequestURI(), rec.status)
+	})
+}
+
+func init() {
+	addParseFlags(mockCmd.Flags())
+	mockCmd.Flags().StringVar(&mockAddr, "addr", "localhost:4010", "The address of the mock")
+	mockCmd.Flags().StringVar(&mockSpec, "spec", "", "An existing document to mock rather than generating it")
+	RootCmd.AddCommand(mockCmd)
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// writeOutput writes the output in a temporary file renamed once it's
+// complete, so that the output is never read half written
+func writeOutput(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// marshal encodes the document in the format of the output, yaml or json
+func marshal(v interface{}, format string) ([]byte, error) {
+	d, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case "yaml", "":
+		return d, nil
+	case "json":
+		return yamlToJSON(d)
+	default:
+		return nil, fmt.Errorf("unknown format %q, yaml or json expected", format)
+	}
+}
+
+// yamlToJSON converts a yaml document to json
+func yamlToJSON(d []byte) ([]byte, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(d, &doc); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(jsonValue(doc), "", "  ")
+}
+
+// jsonValue turns the maps decoded from yaml into maps with string keys
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			m[fmt.Sprint(k)] = jsonValue(value)
+		}
+		return m
+	case []interface{}:
+		for i, value := range v {
+			v[i] = jsonValue(value)
+		}
+		return v
+	}
+	return v
+}
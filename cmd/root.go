@@ -2,21 +2,38 @@ package cmd
 
 import (
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/alexjomin/openapi-parser/docparser"
 	"github.com/spf13/cobra"
-	yaml "gopkg.in/yaml.v2"
+	"github.com/spf13/pflag"
 )
 
 var (
 	outputPath   string
 	inputPath    string
 	parseVendors []string
+	parseModules []string
 	vendorsPath  string
 	exitError    bool
+	format       string
+	configPath   string
+	env          string
+	include      []string
+	exclude      []string
+	tags         []string
+	jobs         int
+	cacheDir     string
+	noCache      bool
+
+	genericNameTemplate   string
+	hoistAnonymousStructs bool
+	fillExamples          bool
+	defaultResponses      []string
+
+	watchMode bool
 )
 
 // RootCmd represents the root command
@@ -25,16 +42,192 @@ var RootCmd = &cobra.Command{
 	Short: "OpenAPI Parser ",
 	Long:  `Parse comments in code to generate an OpenAPI documentation`,
 	Run: func(cmd *cobra.Command, args []string) {
-		spec := docparser.NewOpenAPI()
-		spec.Parse(inputPath, parseVendors, vendorsPath, exitError)
-		d, err := yaml.Marshal(&spec)
+		load := func() (docparser.Config, string, error) {
+			c, file, err := effectiveConfig(cmd.Flags())
+			return outputConfig(cmd.Flags(), c), file, err
+		}
+		c, _, err := load()
 		if err != nil {
 			log.Fatalf("error: %v", err)
 		}
-		_ = ioutil.WriteFile(outputPath, d, 0644)
+
+		if watchMode {
+			if err := watch(load, func(c docparser.Config) (generation, error) {
+				return generate(c, false)
+			}); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			return
+		}
+		if _, err := generate(c, c.ExitError); err != nil {
+			log.Fatalf("error: %v", err)
+		}
 	},
 }
 
+// generation is what is known of a generation of the document, dirs are the
+// folders to watch
+type generation struct {
+	files    []string
+	dirs     []string
+	errors   []error
+	warnings []string
+}
+
+// generate parses the code and writes the document to the output
+func generate(c docparser.Config, exitError bool) (generation, error) {
+	g, d, err := build(c, exitError, c.Format)
+	if err != nil {
+		return g, err
+	}
+	return g, writeOutput(c.Output, d)
+}
+
+// build parses the code and returns the document in the format
+func build(c docparser.Config, exitError bool, format string) (generation, []byte, error) {
+	spec := docparser.NewOpenAPI()
+	if err := spec.Configure(c); err != nil {
+		return generation{}, nil, err
+	}
+	err := spec.Parse(c.Paths, c.Vendors, c.VendorsPath, exitError)
+	g := generation{files: spec.Files(), dirs: spec.Dirs(c.Paths), errors: spec.Errors(), warnings: spec.Warnings()}
+	if err != nil {
+		return g, nil, err
+	}
+
+	d, err := marshal(&spec, format)
+	return g, d, err
+}
+
+// addOutputFlags adds the flags setting the output of the document, see
+// outputConfig
+func addOutputFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&outputPath, "output", "openapi.yaml", "The output file")
+	flags.StringVar(&format, "format", "yaml", "The format of the output, yaml or json")
+}
+
+// addParseFlags adds the flags setting the configuration of the parser
+func addParseFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&inputPath, "path", ".", "The Folder to parse")
+	flags.StringArrayVar(&parseVendors, "parse-vendors", []string{}, "Give the vendor to parse")
+	flags.StringArrayVar(&parseModules, "parse-module", []string{}, "Give a module, or a package of a module, required by the go.mod to parse, it's found in the module cache or a replace directive")
+	flags.StringVar(&vendorsPath, "vendors-path", "vendor", "Give the vendor path")
+	flags.BoolVar(&exitError, "exit-error", false, "When an error occurs on parsing, exit with a code > 0")
+	flags.StringArrayVar(&include, "include", []string{}, "A doublestar pattern of the files to parse, relative to the folder to parse, i.e. api/**/*.go")
+	flags.StringArrayVar(&exclude, "exclude", []string{}, "A doublestar pattern of the files and folders not to parse, relative to the folder to parse, or a base name")
+	flags.StringSliceVar(&tags, "tags", []string{}, "A comma-separated list of build tags satisfied by the files to parse")
+	flags.IntVar(&jobs, "jobs", 0, "The number of files parsed concurrently, by default the number of CPUs")
+	flags.StringVar(&cacheDir, "cache-dir", "", "The folder of the cache of the parsed files, by default a folder of the cache folder of the user")
+	flags.BoolVar(&noCache, "no-cache", false, "Parse every file rather than reading the unchanged ones from the cache")
+	flags.StringVar(&configPath, "config", "", "The configuration file, by default "+docparser.ConfigFile+" is looked for in the folder to parse and its parents")
+	flags.StringVar(&env, "env", "", "The environment of the servers of the configuration file, by default the servers of every environment")
+	flags.StringVar(&genericNameTemplate, "generic-name-template", docparser.DefaultGenericNameTemplate, "The template used to name the schemas of instantiated generic types")
+	flags.BoolVar(&hoistAnonymousStructs, "hoist-anonymous-structs", false, "Register the anonymous structs as schemas named after their struct and field")
+	flags.BoolVar(&fillExamples, "fill-examples", false, "Fill the examples of the schemas, the responses and the request bodies which have none, synthesised from their schemas")
+	flags.StringArrayVar(&defaultResponses, "default-response", []string{}, "A response added to every operation which doesn't define its status code, written code=ref where ref is the $ref of a response or a Go type")
+}
+
+// effectiveConfig returns the configuration of the file overridden by the
+// flags which are set, and the path of the file
+func effectiveConfig(flags *pflag.FlagSet) (docparser.Config, string, error) {
+	c := docparser.DefaultConfig()
+
+	file := configPath
+	if file == "" {
+		var err error
+		if file, err = docparser.FindConfig(inputPath); err != nil {
+			return c, "", err
+		}
+	}
+	if file != "" {
+		var err error
+		if c, err = docparser.LoadConfig(file, c); err != nil {
+			return c, file, err
+		}
+	}
+
+	responses, err := parseDefaultResponses(defaultResponses)
+	if err != nil {
+		return c, file, err
+	}
+
+	set := docparser.Config{DefaultResponses: responses}
+	if flags.Changed("path") {
+		set.Paths = []string{inputPath}
+	}
+	if flags.Changed("parse-vendors") {
+		set.Vendors = parseVendors
+	}
+	if flags.Changed("parse-module") {
+		set.Modules = parseModules
+	}
+	if flags.Changed("vendors-path") {
+		set.VendorsPath = vendorsPath
+	}
+	if flags.Changed("include") {
+		set.Include = include
+	}
+	if flags.Changed("exclude") {
+		set.Exclude = exclude
+	}
+	if flags.Changed("tags") {
+		set.Tags = tags
+	}
+	if flags.Changed("jobs") {
+		set.Jobs = jobs
+	}
+	if flags.Changed("cache-dir") {
+		set.CacheDir = cacheDir
+	}
+	if flags.Changed("env") {
+		set.Env = env
+	}
+	if flags.Changed("generic-name-template") {
+		set.GenericNameTemplate = genericNameTemplate
+	}
+	c = c.Override(set)
+
+	if flags.Changed("exit-error") {
+		c.ExitError = exitError
+	}
+	if flags.Changed("hoist-anonymous-structs") {
+		c.HoistAnonymousStructs = hoistAnonymousStructs
+	}
+	if flags.Changed("fill-examples") {
+		c.FillExamples = fillExamples
+	}
+	if flags.Changed("no-cache") {
+		c.NoCache = noCache
+	}
+	return c, file, nil
+}
+
+// outputConfig returns the configuration overridden by the flags of
+// addOutputFlags which are set
+func outputConfig(flags *pflag.FlagSet, c docparser.Config) docparser.Config {
+	set := docparser.Config{}
+	if flags.Changed("output") {
+		set.Output = outputPath
+	}
+	if flags.Changed("format") {
+		set.Format = format
+	}
+	return c.Override(set)
+}
+
+// parseDefaultResponses reads the default responses given as code=ref
+func parseDefaultResponses(values []string) (map[string]string, error) {
+	responses := make(map[string]string, len(values))
+	for _, v := range values {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("default response %q must be written code=ref", v)
+		}
+		responses[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return responses, nil
+}
+
 // Execute adds all child commands to the root command sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -45,9 +238,7 @@ func Execute() {
 }
 
 func init() {
-	RootCmd.Flags().StringVar(&outputPath, "output", "openapi.yaml", "The output file")
-	RootCmd.Flags().StringVar(&inputPath, "path", ".", "The Folder to parse")
-	RootCmd.Flags().StringArrayVar(&parseVendors, "parse-vendors", []string{}, "Give the vendor to parse")
-	RootCmd.Flags().StringVar(&vendorsPath, "vendors-path", "vendor", "Give the vendor path")
-	RootCmd.Flags().BoolVar(&exitError, "exit-error", false, "When an error occurs on parsing, exit with a code > 0")
+	addParseFlags(RootCmd.Flags())
+	addOutputFlags(RootCmd.Flags())
+	RootCmd.Flags().BoolVar(&watchMode, "watch", false, "Regenerate the output when the go files of the parsed folders change")
 }
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"path/filepath"
+	"sync"
+
+	"github.com/alexjomin/openapi-parser/docparser"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+)
+
+// ui is the documentation UI, it doesn't load anything but the document
+//
+//go:embed ui
+var ui embed.FS
+
+var (
+	serveAddr  string
+	serveSpec  string
+	serveWatch bool
+)
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the documentation of the document",
+	Long: `Generate the document, or load an existing file with --spec, and serve it
+with an offline documentation UI. With --watch the document is generated or
+loaded again when it changes, and the UI reloads.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, _, err := effectiveConfig(cmd.Flags())
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+
+		s := &docServer{clients: make(map[chan struct{}]bool)}
+		handler, err := s.handler()
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+		ln, err := net.Listen("tcp", serveAddr)
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+		served := make(chan error, 1)
+		go func() {
+			served <- http.Serve(ln, handler)
+		}()
+		log.Printf("Serving the documentation on http://%s", ln.Addr())
+
+		switch {
+		case serveWatch && serveSpec != "":
+			err = s.watchFile(serveSpec)
+		case serveWatch:
+			err = watch(func() (docparser.Config, string, error) {
+				return effectiveConfig(cmd.Flags())
+			}, func(c docparser.Config) (generation, error) {
+				g, d, err := build(c, false, "yaml")
+				if err != nil {
+					return g, err
+				}
+				return g, s.publish(d)
+			})
+		case serveSpec != "":
+			if err = s.loadFile(serveSpec); err == nil {
+				err = <-served
+			}
+		default:
+			var d []byte
+			if _, d, err = build(c, c.ExitError, "yaml"); err == nil {
+				if err = s.publish(d); err == nil {
+					err = <-served
+				}
+			}
+		}
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+	},
+}
+
+// docServer serves the documentation UI and the last version of the document
+type docServer struct {
+	mu   sync.Mutex
+	yaml []byte
+	json []byte
+	// clients are notified when the document changes
+	clients map[chan struct{}]bool
+}
+
+// publish replaces the document and notifies the clients
+func (s *docServer) publish(d []byte) error {
+	j, err := yamlToJSON(d)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.yaml, s.json = d, j
+	for c := range s.clients {
+		select {
+		case c <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// loadFile publishes a document file
+func (s *docServer) loadFile(path string) error {
+	d, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return s.publish(d)
+}
+
+// watchFile publishes a document file whenever it changes
+func (s *docServer) watchFile(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if err := s.loadFile(path); err != nil {
+		return err
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	// the folder is watched since the editors often replace the file
+	if err := w.Add(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	return watchEvents(w, func(ev fsnotify.Event) bool {
+		return ev.Name == path && ev.Op&(fsnotify.Write|fsnotify.Create) != 0
+	}, func() {
+		if err := s.loadFile(path); err != nil {
+			log.Printf("error: %v", err)
+			return
+		}
+		log.Printf("%s loaded", path)
+	})
+}
+
+func (s *docServer) handler() (http.Handler, error) {
+	assets, err := fs.Sub(ui, "ui")
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.FS(assets)))
+	mux.HandleFunc("/openapi.yaml", func(w http.ResponseWriter, r *http.Request) {
+		s.serveDocument(w, "application/yaml", func() []byte { return s.yaml })
+	})
+	mux.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		s.serveDocument(w, "application/json", func() []byte { return s.json })
+	})
+	mux.HandleFunc("/events", s.events)
+	return mux, nil
+}
+
+func (s *docServer) serveDocument(w http.ResponseWriter, contentType string, doc func() []byte) {
+	s.mu.Lock()
+	d := doc()
+	s.mu.Unlock()
+
+	if d == nil {
+		http.Error(w, "The document isn't generated yet", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write(d)
+}
+
+// events notifies the UI that the document changed, with server-sent events
+func (s *docServer) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	c := make(chan struct{}, 1)
+	s.mu.Lock()
+	s.clients[c] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, c)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+	for {
+		select {
+		case <-c:
+			fmt.Fprint(w, "data: reload\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func init() {
+	addParseFlags(serveCmd.Flags())
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "localhost:8080", "The address of the server")
+	serveCmd.Flags().StringVar(&serveSpec, "spec", "", "An existing document to serve rather than generating it")
+	serveCmd.Flags().BoolVar(&serveWatch, "watch", false, "Generate or load the document again when it changes, the UI reloads")
+	RootCmd.AddCommand(serveCmd)
+}
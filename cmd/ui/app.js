@@ -0,0 +1,329 @@
+// Renders the OpenAPI document served by openapi-parser serve, and renders it
+// again when the server reports that it changed.
+(function () {
+  'use strict';
+
+  var verbs = ['get', 'put', 'post', 'delete', 'options', 'head', 'patch', 'trace'];
+  var schemaPrefix = '#/components/schemas/';
+
+  function escape(s) {
+    return String(s === undefined || s === null ? '' : s)
+      .replace(/&/g, '&amp;')
+      .replace(/</g, '&lt;')
+      .replace(/>/g, '&gt;')
+      .replace(/"/g, '&quot;');
+  }
+
+  function anchor(s) {
+    return s.replace(/[^\w-]+/g, '-');
+  }
+
+  function schemaLink(ref) {
+    var name = ref.indexOf(schemaPrefix) === 0 ? ref.slice(schemaPrefix.length) : ref;
+    return '<a href="#schema-' + anchor(name) + '">' + escape(name) + '</a>';
+  }
+
+  // typeOf describes the type of a schema on one line
+  function typeOf(s) {
+    if (!s) {
+      return '';
+    }
+    if (s.$ref) {
+      return schemaLink(s.$ref);
+    }
+    if (s.allOf || s.oneOf || s.anyOf) {
+      var kind = s.allOf ? 'allOf' : s.oneOf ? 'oneOf' : 'anyOf';
+      return kind + '(' + s[kind].map(typeOf).join(', ') + ')';
+    }
+    if (s.type === 'array') {
+      return 'array of ' + typeOf(s.items);
+    }
+    if (s.type === 'object' && s.additionalProperties && typeof s.additionalProperties === 'object') {
+      return 'map of ' + typeOf(s.additionalProperties);
+    }
+    var t = escape(s.type || 'any');
+    if (s.format) {
+      t += ' <code>' + escape(s.format) + '</code>';
+    }
+    if (s.nullable) {
+      t += ' | null';
+    }
+    return t;
+  }
+
+  // properties renders the properties of an object schema, the nested objects
+  // are rendered below their property
+  function properties(s, depth) {
+    if (!s || depth > 4) {
+      return '';
+    }
+    if (s.allOf) {
+      return s.allOf.map(function (part) {
+        return part.$ref ? '<p>Includes ' + schemaLink(part.$ref) + '</p>' : properties(part, depth);
+      }).join('');
+    }
+    if (s.type === 'array' && s.items && !s.items.$ref) {
+      return properties(s.items, depth);
+    }
+    if (!s.properties) {
+      return '';
+    }
+    var required = s.required || [];
+    var rows = Object.keys(s.properties).sort().map(function (name) {
+      var p = s.properties[name] || {};
+      var details = [];
+      if (p.description) {
+        details.push(escape(p.description));
+      }
+      if (p.enum) {
+        details.push('One of ' + p.enum.map(function (v) { return '<code>' + escape(JSON.stringify(v)) + '</code>'; }).join(', '));
+      }
+      if (p.example !== undefined) {
+        details.push('Example <code>' + escape(JSON.stringify(p.example)) + '</code>');
+      }
+      return '<tr><td><code>' + escape(name) + '</code>' +
+        (required.indexOf(name) >= 0 ? ' <span class="required">*</span>' : '') +
+        '</td><td>' + typeOf(p) + '</td><td>' + details.join('<br>') + properties(p, depth + 1) + '</td></tr>';
+    });
+    return '<table><tr><th>Property</th><th>Type</th><th>Description</th></tr>' + rows.join('') + '</table>';
+  }
+
+  function schemaBlock(s) {
+    if (!s) {
+      return '';
+    }
+    var html = '<p>' + typeOf(s) + '</p>' + properties(s, 0);
+    if (s.example !== undefined) {
+      html += '<pre>' + escape(JSON.stringify(s.example, null, 2)) + '</pre>';
+    }
+    return html;
+  }
+
+  function content(c) {
+    return Object.keys(c || {}).map(function (type) {
+      var media = c[type] || {};
+      var html = '<p><code>' + escape(type) + '</code></p>' + schemaBlock(media.schema);
+      if (media.example !== undefined) {
+        html += '<pre>' + escape(JSON.stringify(media.example, null, 2)) + '</pre>';
+      }
+      Object.keys(media.examples || {}).forEach(function (name) {
+        var e = media.examples[name] || {};
+        if (e.value !== undefined) {
+          html += '<p>' + escape(e.summary || name) + '</p><pre>' + escape(JSON.stringify(e.value, null, 2)) + '</pre>';
+        }
+      });
+      return html;
+    }).join('');
+  }
+
+  function parameters(params) {
+    if (!params || params.length === 0) {
+      return '';
+    }
+    var rows = params.map(function (p) {
+      if (p.$ref) {
+        return '<tr><td colspan="4"><code>' + escape(p.$ref) + '</code></td></tr>';
+      }
+      return '<tr><td><code>' + escape(p.name) + '</code>' + (p.required ? ' <span class="required">*</span>' : '') +
+        '</td><td>' + escape(p.in) + '</td><td>' + typeOf(p.schema) + '</td><td>' + escape(p.description) + '</td></tr>';
+    });
+    return '<h4>Parameters</h4><table><tr><th>Name</th><th>In</th><th>Type</th><th>Description</th></tr>' + rows.join('') + '</table>';
+  }
+
+  // pathItems calls fn with each operation of the path items, by url then by
+  // verb, the extensions and the $ref are skipped
+  function pathItems(items, fn) {
+    Object.keys(items || {}).sort().forEach(function (url) {
+      if (url === '$ref' || url.indexOf('x-') === 0) {
+        return;
+      }
+      var item = items[url] || {};
+      verbs.forEach(function (verb) {
+        if (item[verb]) {
+          fn(url, verb, item[verb], item.parameters);
+        }
+      });
+    });
+  }
+
+  // callbacks renders the callbacks of an operation, the callbacks referenced
+  // from the components are resolved
+  function callbacks(doc, id, cbs) {
+    var names = Object.keys(cbs || {}).sort();
+    if (names.length === 0) {
+      return '';
+    }
+    var components = (doc.components && doc.components.callbacks) || {};
+    var html = '<h4>Callbacks</h4>';
+    names.forEach(function (name) {
+      var items = cbs[name] || {};
+      if (items.$ref) {
+        items = components[items.$ref.slice(items.$ref.lastIndexOf('/') + 1)] || {};
+      }
+      pathItems(items, function (url, verb, op, params) {
+        html += operation(doc, id + '-' + anchor(name + '-' + verb + url), url, verb, op, params, name);
+      });
+    });
+    return html;
+  }
+
+  // operation renders an operation, callback is the name of the callback the
+  // operation belongs to
+  function operation(doc, id, url, verb, op, pathParams, callback) {
+    var html = '<section class="operation' + (callback ? ' callback' : '') + (op.deprecated ? ' deprecated' : '') + '" id="' + id + '">' +
+      '<h3>' + (callback ? escape(callback) + ' ' : '') +
+      '<span class="method ' + verb + '">' + verb + '</span><span class="path">' + escape(url) + '</span></h3>';
+    if (op.summary) {
+      html += '<p><strong>' + escape(op.summary) + '</strong></p>';
+    }
+    if (op.description) {
+      html += '<p>' + escape(op.description) + '</p>';
+    }
+    html += parameters((pathParams || []).concat(op.parameters || []));
+    if (op.requestBody) {
+      html += '<h4>Request body</h4>' + (op.requestBody.$ref ? '<p><code>' + escape(op.requestBody.$ref) + '</code></p>' : content(op.requestBody.content));
+    }
+    var responses = op.responses || {};
+    var codes = Object.keys(responses).sort();
+    if (codes.length > 0) {
+      html += '<h4>Responses</h4>';
+      codes.forEach(function (code) {
+        var r = responses[code] || {};
+        html += '<p><code>' + escape(code) + '</code> ' + (r.$ref ? '<code>' + escape(r.$ref) + '</code>' : escape(r.description)) + '</p>' + content(r.content);
+      });
+    }
+    html += callbacks(doc, id, op.callbacks);
+    return html + '</section>';
+  }
+
+  // operations returns the operations of the document by tag
+  function operationsByTag(doc) {
+    var byTag = {};
+    pathItems(doc.paths, function (url, verb, op, params) {
+      (op.tags && op.tags.length ? op.tags : ['default']).forEach(function (tag) {
+        (byTag[tag] = byTag[tag] || []).push({ url: url, verb: verb, op: op, params: params });
+      });
+    });
+    return byTag;
+  }
+
+  // tagGroups returns the tags by group, the tags without a group are in a
+  // group without a name
+  function tagGroups(doc, byTag) {
+    var groups = (doc['x-tagGroups'] || []).map(function (g) {
+      return { name: g.name, tags: g.tags || [] };
+    });
+    var grouped = {};
+    groups.forEach(function (g) {
+      g.tags.forEach(function (t) { grouped[t] = true; });
+    });
+    var declared = (doc.tags || []).map(function (t) { return t.name; });
+    var others = declared.concat(Object.keys(byTag).sort()).filter(function (t, i, all) {
+      return !grouped[t] && all.indexOf(t) === i;
+    });
+    if (others.length > 0) {
+      groups.push({ name: '', tags: others });
+    }
+    return groups;
+  }
+
+  function render(doc) {
+    var info = doc.info || {};
+    var byTag = operationsByTag(doc);
+    var descriptions = {};
+    (doc.tags || []).forEach(function (t) { descriptions[t.name] = t.description; });
+
+    var nav = '<h2>' + escape(info.title || 'API') + '</h2>';
+    var html = '<h1>' + escape(info.title || 'API') + ' <small>' + escape(info.version) + '</small></h1>';
+    if (info.description) {
+      html += '<p>' + escape(info.description) + '</p>';
+    }
+    if (doc.servers && doc.servers.length) {
+      html += '<h2>Servers</h2><ul>' + doc.servers.map(function (s) {
+        return '<li><code>' + escape(s.url) + '</code> ' + escape(s.description) + '</li>';
+      }).join('') + '</ul>';
+    }
+
+    tagGroups(doc, byTag).forEach(function (group) {
+      if (group.name) {
+        nav += '<h2>' + escape(group.name) + '</h2>';
+        html += '<h2>' + escape(group.name) + '</h2>';
+      }
+      group.tags.forEach(function (tag) {
+        var ops = byTag[tag] || [];
+        if (ops.length === 0) {
+          return;
+        }
+        nav += '<h3><a href="#tag-' + anchor(tag) + '">' + escape(tag) + '</a></h3>';
+        html += '<h2 id="tag-' + anchor(tag) + '">' + escape(tag) + '</h2>';
+        if (descriptions[tag]) {
+          html += '<p>' + escape(descriptions[tag]) + '</p>';
+        }
+        ops.forEach(function (o) {
+          nav += '<a href="#op-' + anchor(o.verb + o.url) + '"><span class="method ' + o.verb + '">' + o.verb + '</span>' + escape(o.url) + '</a>';
+          html += operation(doc, 'op-' + anchor(o.verb + o.url), o.url, o.verb, o.op, o.params);
+        });
+      });
+    });
+
+    var webhooksNav = '';
+    var webhooks = '';
+    pathItems(doc.webhooks, function (name, verb, op, params) {
+      var id = 'webhook-' + anchor(verb + name);
+      webhooksNav += '<a href="#' + id + '"><span class="method ' + verb + '">' + verb + '</span>' + escape(name) + '</a>';
+      webhooks += operation(doc, id, name, verb, op, params);
+    });
+    if (webhooks) {
+      nav += '<h2>Webhooks</h2>' + webhooksNav;
+      html += '<h2 id="webhooks">Webhooks</h2>' + webhooks;
+    }
+
+    var schemas = (doc.components && doc.components.schemas) || {};
+    var names = Object.keys(schemas).sort();
+    if (names.length > 0) {
+      nav += '<h2>Schemas</h2>';
+      html += '<h2>Schemas</h2>';
+      names.forEach(function (name) {
+        nav += '<a href="#schema-' + anchor(name) + '">' + escape(name) + '</a>';
+        html += '<section class="schema" id="schema-' + anchor(name) + '"><h3>' + escape(name) + '</h3>' +
+          (schemas[name] && schemas[name].description ? '<p>' + escape(schemas[name].description) + '</p>' : '') +
+          schemaBlock(schemas[name]) + '</section>';
+      });
+    }
+
+    document.title = info.title || 'API documentation';
+    document.getElementById('nav').innerHTML = nav;
+    document.getElementById('doc').innerHTML = html +
+      '<p class="status">Updated at ' + new Date().toLocaleTimeString() + '</p>';
+  }
+
+  function load() {
+    var scroll = window.scrollY;
+    return fetch('openapi.json', { cache: 'no-store' })
+      .then(function (r) {
+        if (!r.ok) {
+          throw new Error(r.status + ' ' + r.statusText);
+        }
+        return r.json();
+      })
+      .then(function (doc) {
+        render(doc);
+        window.scrollTo(0, scroll);
+      })
+      .catch(function (err) {
+        document.getElementById('doc').innerHTML = '<p class="status error">Unable to load the document: ' + escape(err.message) + '</p>';
+      });
+  }
+
+  load().then(function () {
+    if (location.hash) {
+      var target = document.getElementById(location.hash.slice(1));
+      if (target) {
+        target.scrollIntoView();
+      }
+    }
+  });
+  if (window.EventSource) {
+    new EventSource('events').onmessage = load;
+  }
+})();
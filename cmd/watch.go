@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alexjomin/openapi-parser/docparser"
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is the time waited after a change before regenerating the
+// document, so that the files saved together are parsed once
+const watchDebounce = 300 * time.Millisecond
+
+// watch generates the document with regenerate, then regenerates it whenever
+// a go file of the parsed folders, the go.mod or the configuration file
+// changes. The configuration is loaded again by load before each generation.
+// Only the changed files are parsed again, the others are read from the cache.
+func watch(load func() (docparser.Config, string, error), regenerate func(docparser.Config) (generation, error)) error {
+	c, _, err := load()
+	if err != nil {
+		return err
+	}
+	cacheDir := ""
+	if c.NoCache {
+		dir, err := ioutil.TempDir("", "openapi-parser-cache")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		cacheDir = dir
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	watched := make(map[string]bool)
+	add := func(dir string) {
+		if watched[dir] {
+			return
+		}
+		if err := w.Add(dir); err != nil {
+			log.Printf("unable to watch %s: %v", dir, err)
+			return
+		}
+		watched[dir] = true
+	}
+
+	reported := make(map[string]bool)
+	run := func() {
+		start := time.Now()
+		c, file, err := load()
+		if err != nil {
+			log.Printf("error: %v", err)
+			return
+		}
+		if file != "" {
+			add(filepath.Dir(file))
+		}
+		if cacheDir != "" {
+			c.NoCache = false
+			c.CacheDir = cacheDir
+		}
+
+		g, err := regenerate(c)
+		for _, dir := range g.dirs {
+			add(dir)
+		}
+		for _, f := range g.files {
+			add(filepath.Dir(f))
+		}
+		if err != nil {
+			log.Printf("error: %v", err)
+			return
+		}
+		reported = printDiagnostics(g, reported)
+		log.Printf("Document generated from %d files in %s, watching for changes", len(g.files), time.Since(start).Round(time.Millisecond))
+	}
+	run()
+
+	return watchEvents(w, func(ev fsnotify.Event) bool {
+		if ev.Op&fsnotify.Create != 0 {
+			// a new folder may contain files to parse
+			if fi, err := os.Stat(ev.Name); err == nil && fi.IsDir() {
+				add(ev.Name)
+				return true
+			}
+		}
+		return isWatchedFile(ev.Name) && ev.Op&fsnotify.Chmod != ev.Op
+	}, run)
+}
+
+// watchEvents calls fn once the events matched by match stop for
+// watchDebounce, until the process is interrupted
+func watchEvents(w *fsnotify.Watcher, match func(fsnotify.Event) bool, fn func()) error {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	var pending <-chan time.Time
+	for {
+		select {
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if match(ev) {
+				pending = time.After(watchDebounce)
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			log.Printf("watch error: %v", err)
+		case <-pending:
+			pending = nil
+			fn()
+		case <-interrupt:
+			return nil
+		}
+	}
+}
+
+// isWatchedFile tells if a change of the file changes the document: a go
+// file, the go.mod or the configuration file
+func isWatchedFile(path string) bool {
+	switch filepath.Base(path) {
+	case "go.mod", docparser.ConfigFile:
+		return true
+	}
+	return strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go")
+}
+
+// diagnostic returns the message of an error, on one line
+func diagnostic(err error) string {
+	var be *docparser.BuildError
+	if errors.As(err, &be) {
+		return buildDiagnostic(*be)
+	}
+	if be, ok := err.(docparser.BuildError); ok {
+		return buildDiagnostic(be)
+	}
+	return err.Error()
+}
+
+func buildDiagnostic(be docparser.BuildError) string {
+	if be.Message != "" {
+		return fmt.Sprintf("%s: %v", be.Message, be.Err)
+	}
+	content := strings.SplitN(strings.TrimSpace(be.Content), "\n", 2)[0]
+	return fmt.Sprintf("%v: %s", be.Err, content)
+}
+
+// printDiagnostics prints the errors and the warnings which weren't reported
+// by the previous generation, and returns the ones reported
+func printDiagnostics(g generation, previous map[string]bool) map[string]bool {
+	current := make(map[string]bool)
+	diagnostics := []string{}
+	for _, err := range g.errors {
+		diagnostics = append(diagnostics, "error: "+diagnostic(err))
+	}
+	for _, warning := range g.warnings {
+		diagnostics = append(diagnostics, "warning: "+warning)
+	}
+	sort.Strings(diagnostics)
+
+	for _, d := range diagnostics {
+		if !previous[d] && !current[d] {
+			log.Print(d)
+		}
+		current[d] = true
+	}
+	fixed := 0
+	for d := range previous {
+		if !current[d] {
+			fixed++
+		}
+	}
+	if fixed > 0 {
+		log.Printf("%d errors and warnings fixed", fixed)
+	}
+	return current
+}
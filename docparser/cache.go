@@ -0,0 +1,441 @@
+package docparser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go/ast"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// cacheFormat is the version of the cache entries, it's changed when what is
+// cached of a file changes
+const cacheFormat = "5"
+
+// modulePath is the path of the parser module, its version is the version of
+// the parser
+const modulePath = "github.com/alexjomin/openapi-parser"
+
+var (
+	parserVersionOnce sync.Once
+	parserVersion     string
+)
+
+// version returns the version of the parser, or the hash of the executable for
+// a development build, so that a new parser never reads the results of an
+// older one. It's empty when it can't be known.
+func version() string {
+	parserVersionOnce.Do(func() {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			modules := append([]*debug.Module{&info.Main}, info.Deps...)
+			for _, m := range modules {
+				if m.Path == modulePath && m.Replace == nil && m.Version != "" && m.Version != "(devel)" {
+					parserVersion = m.Version
+					return
+				}
+			}
+		}
+
+		exe, err := os.Executable()
+		if err == nil {
+			var f *os.File
+			if f, err = os.Open(exe); err == nil {
+				defer f.Close()
+				h := sha256.New()
+				if _, err = io.Copy(h, f); err == nil {
+					parserVersion = hex.EncodeToString(h.Sum(nil))
+					return
+				}
+			}
+		}
+		logrus.WithError(err).Warn("Unable to find the version of the parser")
+	})
+	return parserVersion
+}
+
+// cacheEntry is what is cached of a parsed file: its document, the schemas
+// registered, the declarations, the handlers, the errors and the warnings
+type cacheEntry struct {
+	Generated bool                    `yaml:"generated,omitempty"`
+	Spec      *cachedSpec             `yaml:"spec,omitempty"`
+	TagGroups []tagGroup              `yaml:"tagGroups,omitempty"`
+	Schemas   map[string]cachedSchema `yaml:"schemas,omitempty"`
+	Types     []string                `yaml:"types,omitempty"`
+	Funcs     []string                `yaml:"funcs,omitempty"`
+	Handlers  []cachedHandler         `yaml:"handlers,omitempty"`
+	Errors    []cachedError           `yaml:"errors,omitempty"`
+	Warnings  []string                `yaml:"warnings,omitempty"`
+}
+
+// cachedSpec is a document cached as it is, the fields used to expand the
+// parameters and the request bodies aren't dropped when it's read
+type cachedSpec openAPI
+
+type cachedSchema struct {
+	CustomName string          `yaml:"customName,omitempty"`
+	Schema     *schema         `yaml:"schema,omitempty"`
+	Composed   *composedSchema `yaml:"composed,omitempty"`
+	// Examples are the positions of the @openapi:example annotations by path
+	// of their schema, see examplePositions
+	Examples map[string]string `yaml:"examples,omitempty"`
+}
+
+type cachedHandler struct {
+	Pkg   string `yaml:"pkg"`
+	URL   string `yaml:"url"`
+	Verb  string `yaml:"verb"`
+	Index int    `yaml:"index"`
+}
+
+type cachedError struct {
+	Err     string `yaml:"err,omitempty"`
+	Content string `yaml:"content,omitempty"`
+	Message string `yaml:"message,omitempty"`
+	// Plain is set by the errors which aren't build errors
+	Plain bool `yaml:"plain,omitempty"`
+}
+
+// DefaultCacheDir returns the folder of the cache in the cache folder of the
+// user
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "openapi-parser"), nil
+}
+
+// CleanCache removes the cache folder dir
+func CleanCache(dir string) error {
+	if dir == "" {
+		return errors.New("no cache folder")
+	}
+	return os.RemoveAll(dir)
+}
+
+// SetCacheDir sets the folder of the cache, the results of the unchanged
+// files are read from the cache rather than parsed again. The cache is
+// disabled when dir is empty, or when the version of the parser is unknown.
+func (spec *openAPI) SetCacheDir(dir string) {
+	if dir != "" && version() == "" {
+		logrus.Warn("The cache is disabled, the version of the parser is unknown")
+		dir = ""
+	}
+	spec.cacheDir = dir
+}
+
+// cacheKey returns the key of the content of a file, for the version of the
+// parser and the options changing what is parsed in a file. The path and the
+// import path of the package of the file are part of the key: the cached
+// types, funcs and positions depend on them.
+func (spec *openAPI) cacheKey(path, pkgPath string, data []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%t\x00", cacheFormat, version(), spec.hoistAnonymousStructs)
+	fmt.Fprintf(h, "%s\x00%s\x00", path, pkgPath)
+	codes := make([]string, 0, len(spec.defaultResponses))
+	for code := range spec.defaultResponses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		fmt.Fprintf(h, "%s=%s\x00", code, spec.defaultResponses[code])
+	}
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cachePath returns the path of the entry of a key
+func (spec *openAPI) cachePath(key string) string {
+	return filepath.Join(spec.cacheDir, key[:2], key+".yaml")
+}
+
+// loadCached returns the result of a file read from the cache, the errors
+// are logged again
+func (spec *openAPI) loadCached(path, key string) (fileResult, bool) {
+	result := fileResult{path: path}
+
+	data, err := ioutil.ReadFile(spec.cachePath(key))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logrus.WithError(err).WithField("file", path).Warn("Unable to read the cache")
+		}
+		return result, false
+	}
+	entry := cacheEntry{}
+	if err := yaml.Unmarshal(data, &entry); err != nil {
+		logrus.WithError(err).WithField("file", path).Warn("Unable to read the cache")
+		return result, false
+	}
+	logrus.WithField("file", path).Debug("Reading file from the cache")
+
+	for _, e := range entry.Errors {
+		if e.Plain {
+			err := errors.New(e.Err)
+			logrus.WithError(err).WithField("file", path).Error("Error parsing file")
+			result.errs = append(result.errs, err)
+			continue
+		}
+		var err error
+		if e.Err != "" {
+			err = errors.New(e.Err)
+		}
+		logrus.
+			WithError(err).
+			WithField("content", e.Content).
+			Error(e.Message)
+		result.errs = append(result.errs, &BuildError{Err: err, Content: e.Content, Message: e.Message})
+	}
+	if entry.Generated || entry.Spec == nil {
+		return result, true
+	}
+
+	file := spec.restoreFileSpec(openAPI(*entry.Spec))
+	for _, g := range entry.TagGroups {
+		file.XGroupTags = append(file.XGroupTags, g)
+	}
+	for name, s := range entry.Schemas {
+		var entity interface{}
+		if s.Composed != nil {
+			s.Composed.metadata = metadata{RealName: name, CustomName: s.CustomName}
+			entity = s.Composed
+		} else if s.Schema != nil {
+			s.Schema.metadata = metadata{RealName: name, CustomName: s.CustomName}
+			entity = s.Schema
+		} else {
+			continue
+		}
+		setExamplePositions(entity, s.Examples)
+		file.registeredSchemas[name] = entity
+	}
+	for _, name := range entry.Types {
+		file.typeDecls[name] = typeDecl{path: path}
+	}
+	for _, key := range entry.Funcs {
+		file.funcDecls[key] = funcDecl{path: path}
+	}
+	for _, h := range entry.Handlers {
+		file.handlers = append(file.handlers, handler{pkg: h.Pkg, url: h.URL, verb: h.Verb, path: path, index: h.Index})
+	}
+	file.warnings = entry.Warnings
+	result.spec = file
+	return result, true
+}
+
+// restoreFileSpec returns the document read from the cache with the unexported
+// fields of the document of a file
+func (spec *openAPI) restoreFileSpec(exported openAPI) *openAPI {
+	file := spec.fileSpec()
+	exported.registeredSchemas = file.registeredSchemas
+	exported.typeDecls = file.typeDecls
+	exported.funcDecls = file.funcDecls
+	exported.handlers = nil
+	exported.hoistAnonymousStructs = file.hoistAnonymousStructs
+	exported.defaultResponses = file.defaultResponses
+	exported.build = file.build
+	if exported.Paths == nil {
+		exported.Paths = make(paths)
+	}
+	if exported.Components.Schemas == nil {
+		exported.Components.Schemas = make(map[string]interface{})
+	}
+	return &exported
+}
+
+// storeCached writes the result of a file in the cache, nothing is cached
+// when the cache is disabled
+func (spec *openAPI) storeCached(key string, result fileResult) {
+	if key == "" {
+		return
+	}
+
+	entry := cacheEntry{Generated: result.spec == nil}
+	for _, err := range result.errs {
+		var be *BuildError
+		if !errors.As(err, &be) {
+			entry.Errors = append(entry.Errors, cachedError{Err: err.Error(), Plain: true})
+			continue
+		}
+		e := cachedError{Content: be.Content, Message: be.Message}
+		if be.Err != nil {
+			e.Err = be.Err.Error()
+		}
+		entry.Errors = append(entry.Errors, e)
+	}
+
+	if file := result.spec; file != nil {
+		doc := cachedSpec(*file)
+		doc.XGroupTags = nil
+		entry.Spec = &doc
+		for _, g := range file.XGroupTags {
+			entry.TagGroups = append(entry.TagGroups, g.(tagGroup))
+		}
+
+		entry.Schemas = make(map[string]cachedSchema)
+		for name, entity := range file.registeredSchemas {
+			switch s := entity.(type) {
+			case *schema:
+				entry.Schemas[name] = cachedSchema{CustomName: s.CustomName(), Schema: s, Examples: spec.examplePositions(s)}
+			case *composedSchema:
+				entry.Schemas[name] = cachedSchema{CustomName: s.CustomName(), Composed: s, Examples: spec.examplePositions(s)}
+			}
+		}
+		for name := range file.typeDecls {
+			entry.Types = append(entry.Types, name)
+		}
+		sort.Strings(entry.Types)
+		for key := range file.funcDecls {
+			entry.Funcs = append(entry.Funcs, key)
+		}
+		sort.Strings(entry.Funcs)
+		entry.Warnings = file.warnings
+		for _, h := range file.handlers {
+			for i, decl := range h.file.Decls {
+				if decl == h.decl {
+					entry.Handlers = append(entry.Handlers, cachedHandler{Pkg: h.pkg, URL: h.url, Verb: h.verb, Index: i})
+					break
+				}
+			}
+		}
+	}
+
+	if err := spec.writeCached(key, entry); err != nil {
+		logrus.WithError(err).WithField("file", result.path).Warn("Unable to write the cache")
+	}
+}
+
+// writeCached writes an entry in a temporary file renamed once it's complete,
+// so that a concurrent run never reads a partial entry
+func (spec *openAPI) writeCached(key string, entry cacheEntry) error {
+	data, err := yaml.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	p := spec.cachePath(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(p), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+// loadFile returns the syntax tree of a file read from the cache, it's parsed
+// once when one of its declarations is needed
+func (spec *openAPI) loadFile(path string) (*ast.File, bool) {
+	if f, ok := spec.astFiles[path]; ok {
+		return f, f != nil
+	}
+	if spec.astFiles == nil {
+		spec.astFiles = make(map[string]*ast.File)
+	}
+
+	// the positions of the file are the ones of the parsed files, for the
+	// examples of its declarations
+	data, err := ioutil.ReadFile(path)
+	var f *ast.File
+	if err == nil {
+		f, err = parseSource(spec.fset, path, data)
+	}
+	if err != nil {
+		logrus.WithError(err).WithField("file", path).Error("Unable to parse file")
+	}
+	spec.astFiles[path] = f
+	return f, f != nil
+}
+
+// typeDecl returns a declared type by key, see typeKey, the file of a type
+// read from the cache is parsed when it's needed
+func (spec *openAPI) typeDecl(key string) (typeDecl, bool) {
+	decl, ok := spec.typeDecls[key]
+	if !ok || decl.spec != nil {
+		return decl, ok
+	}
+
+	f, ok := spec.loadFile(decl.path)
+	if !ok {
+		return decl, false
+	}
+	_, name := splitTypeKey(key)
+	for _, d := range f.Decls {
+		gd, ok := d.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, s := range gd.Specs {
+			if ts, ok := s.(*ast.TypeSpec); ok && ts.Name.Name == name {
+				decl = typeDecl{file: f, spec: ts}
+				spec.typeDecls[key] = decl
+				return decl, true
+			}
+		}
+	}
+	logrus.WithField("file", decl.path).WithField("type", key).Warn("Type not found in the cached file")
+	return decl, false
+}
+
+// funcDecl returns a function by key, see funcKey, the file of a function
+// read from the cache is parsed when it's needed
+func (spec *openAPI) funcDecl(key string) (funcDecl, bool) {
+	decl, ok := spec.funcDecls[key]
+	if !ok || decl.decl != nil {
+		return decl, ok
+	}
+
+	f, ok := spec.loadFile(decl.path)
+	if !ok {
+		return decl, false
+	}
+	found := false
+	// the last function of the key is the one registered, see registerFuncs
+	for _, d := range f.Decls {
+		if fd, ok := d.(*ast.FuncDecl); ok && fd.Body != nil && funcKey(packagePath(decl.path), fd) == key {
+			decl = funcDecl{file: f, decl: fd}
+			found = true
+		}
+	}
+	if !found {
+		logrus.WithField("file", decl.path).WithField("function", key).Warn("Function not found in the cached file")
+		return decl, false
+	}
+	spec.funcDecls[key] = decl
+	return decl, true
+}
+
+// handlerDecl returns the handler read from the cache with its declaration
+func (spec *openAPI) handlerDecl(h handler) (handler, bool) {
+	f, ok := spec.loadFile(h.path)
+	if !ok {
+		return h, false
+	}
+	if h.index >= len(f.Decls) {
+		return h, false
+	}
+	fd, ok := f.Decls[h.index].(*ast.FuncDecl)
+	if !ok {
+		return h, false
+	}
+	h.file, h.decl = f, fd
+	return h, true
+}
@@ -0,0 +1,198 @@
+package docparser
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func parseWithCache(t *testing.T, cacheDir string, paths ...string) interface{} {
+	spec := NewOpenAPI()
+	spec.SetCacheDir(cacheDir)
+	spec.Parse(paths, nil, "vendor", false)
+	d, err := yaml.Marshal(&spec)
+	assert.NoError(t, err)
+
+	var doc interface{}
+	assert.NoError(t, yaml.Unmarshal(d, &doc))
+	return doc
+}
+
+func TestParseCache(t *testing.T) {
+	cacheDir := t.TempDir()
+	expected := parseWithCache(t, "", "datatest")
+
+	// the first run fills the cache, the second one reads it
+	assert.Equal(t, expected, parseWithCache(t, cacheDir, "datatest"))
+	entries, err := filepath.Glob(filepath.Join(cacheDir, "*", "*.yaml"))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, entries)
+	assert.Equal(t, expected, parseWithCache(t, cacheDir, "datatest"))
+
+	assert.NoError(t, CleanCache(cacheDir))
+	_, err = os.Stat(cacheDir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestParseCacheChangedFile(t *testing.T) {
+	dir := t.TempDir()
+	cacheDir := filepath.Join(dir, "cache")
+	src := filepath.Join(dir, "src")
+	writeFiles(t, map[string]string{
+		filepath.Join(src, "pet.go"): `package pets
+
+// @openapi:schema
+type Pet struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+`,
+		filepath.Join(src, "handlers.go"): `package pets
+
+import "net/http"
+
+// @openapi:path
+// /pets:
+//   get:
+//     responses:
+//       200:
+//         description: The pets
+func GetPets(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+`,
+	})
+
+	doc := parseWithCache(t, cacheDir, src).(map[interface{}]interface{})
+	assert.Contains(t, doc["components"].(map[interface{}]interface{})["schemas"], "Pet")
+
+	writeFiles(t, map[string]string{
+		filepath.Join(src, "pet.go"): `package pets
+
+// @openapi:schema
+type Dog struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+`,
+	})
+	doc = parseWithCache(t, cacheDir, src).(map[interface{}]interface{})
+	schemas := doc["components"].(map[interface{}]interface{})["schemas"]
+	assert.Contains(t, schemas, "Dog")
+	assert.NotContains(t, schemas, "Pet")
+	assert.Contains(t, doc["paths"], "/pets")
+}
+
+func TestParseCachePackages(t *testing.T) {
+	dir := t.TempDir()
+	page := `package page
+
+type Page[T any] struct {
+	Items []T ` + "`json:\"items\"`" + `
+}
+`
+	writeFiles(t, map[string]string{
+		filepath.Join(dir, "go.mod"):       "module example.com/pets\n",
+		filepath.Join(dir, "a", "page.go"): page,
+		filepath.Join(dir, "b", "page.go"): page,
+		filepath.Join(dir, "pets.go"): `package pets
+
+import (
+	pa "example.com/pets/a"
+	pb "example.com/pets/b"
+)
+
+// @openapi:schema
+type Pet struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+
+// @openapi:schema
+type Listing struct {
+	Pets    pa.Page[Pet] ` + "`json:\"pets\"`" + `
+	Animals pb.Page[Pet] ` + "`json:\"animals\"`" + `
+}
+`,
+	})
+
+	// the files of a and b have the same content, but not the same entry
+	cacheDir := filepath.Join(dir, "cache")
+	for i := 0; i < 2; i++ {
+		spec := NewOpenAPI()
+		spec.SetCacheDir(cacheDir)
+		spec.Parse([]string{dir}, nil, "vendor", false)
+
+		assert.Contains(t, spec.typeDecls, "example.com/pets/a.Page")
+		assert.Contains(t, spec.typeDecls, "example.com/pets/b.Page")
+		for _, err := range spec.Errors() {
+			assert.NotContains(t, err.Error(), "declared twice")
+			assert.NotContains(t, err.Error(), "not found")
+		}
+	}
+}
+
+func TestParseCacheErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pets.go")
+	writeFiles(t, map[string]string{
+		path: `package pets
+
+// @openapi:path
+// /pets:
+//   get:
+//     responses: [
+func GetPets() {}
+`,
+	})
+
+	spec := NewOpenAPI()
+	spec.SetCacheDir(filepath.Join(dir, "cache"))
+	parsed := spec.parseFiles([]string{path})
+	assert.Len(t, parsed[0].errs, 1)
+
+	// the errors are reported again when the file is read from the cache
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	_, ok := spec.loadCached(path, spec.cacheKey(path, packagePath(path), data))
+	assert.True(t, ok)
+	cached := spec.parseFiles([]string{path})
+	assert.Equal(t, parsed[0].errs[0].(*BuildError).Message, cached[0].errs[0].(*BuildError).Message)
+	assert.Equal(t, parsed[0].errs[0].(*BuildError).Content, cached[0].errs[0].(*BuildError).Content)
+}
+
+func TestTypeDeclFromCache(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "page.go")
+	writeFiles(t, map[string]string{
+		path: `package pets
+
+type Page[T any] struct {
+	Items []T
+}
+
+func helper() {}
+`,
+	})
+
+	spec := NewOpenAPI()
+	page := packagePath(path) + ".Page"
+	spec.typeDecls[page] = typeDecl{path: path}
+	helper := packagePath(path) + ".helper"
+	spec.funcDecls[helper] = funcDecl{path: path}
+
+	decl, ok := spec.typeDecl(page)
+	assert.True(t, ok)
+	assert.Equal(t, "Page", decl.spec.Name.Name)
+	fd, ok := spec.funcDecl(helper)
+	assert.True(t, ok)
+	assert.Equal(t, "helper", fd.decl.Name.Name)
+	// the file is parsed once
+	assert.Same(t, decl.file, fd.file)
+
+	missing := packagePath(path) + ".Missing"
+	spec.typeDecls[missing] = typeDecl{path: path}
+	_, ok = spec.typeDecl(missing)
+	assert.False(t, ok)
+}
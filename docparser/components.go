@@ -0,0 +1,225 @@
+package docparser
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"reflect"
+	"regexp"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// @openapi:parameter Limit
+// in: query
+// name: limit
+var regexpComponent = regexp.MustCompile(`@openapi:(parameter|response|requestBody|header|exampleObject|link|callback) (\w+)\n([^@]*)$`)
+
+type example struct {
+	Ref           string      `yaml:"$ref,omitempty"`
+	Summary       string      `yaml:"summary,omitempty"`
+	Description   string      `yaml:"description,omitempty"`
+	Value         interface{} `yaml:"value,omitempty"`
+	ExternalValue string      `yaml:"externalValue,omitempty"`
+	Extensions    extensions  `yaml:",inline"`
+}
+
+type link struct {
+	Ref          string                 `yaml:"$ref,omitempty"`
+	OperationRef string                 `yaml:"operationRef,omitempty"`
+	OperationID  string                 `yaml:"operationId,omitempty"`
+	Parameters   map[string]interface{} `yaml:"parameters,omitempty"`
+	RequestBody  interface{}            `yaml:"requestBody,omitempty"`
+	Description  string                 `yaml:"description,omitempty"`
+	Server       *server                `yaml:"server,omitempty"`
+	Extensions   extensions             `yaml:",inline"`
+}
+
+// refOnly is the marshalled form of an object which is a reference, the
+// other fields of a reference object must be ignored
+type refOnly struct {
+	Ref string `yaml:"$ref"`
+}
+
+func (p parameter) MarshalYAML() (interface{}, error) {
+	if p.Ref != "" {
+		return refOnly{Ref: p.Ref}, nil
+	}
+	type plain parameter
+	return plain(p), nil
+}
+
+func (r response) MarshalYAML() (interface{}, error) {
+	if r.Ref != "" {
+		return refOnly{Ref: r.Ref}, nil
+	}
+	type plain response
+	return plain(r), nil
+}
+
+func (r requestBody) MarshalYAML() (interface{}, error) {
+	if r.Ref != "" {
+		return refOnly{Ref: r.Ref}, nil
+	}
+	type plain requestBody
+	return plain(r), nil
+}
+
+func (h header) MarshalYAML() (interface{}, error) {
+	if h.Ref != "" {
+		return refOnly{Ref: h.Ref}, nil
+	}
+	type plain header
+	return plain(h), nil
+}
+
+// components returns the map of components of a kind, as named in annotations
+func (c *Components) components(kind string) interface{} {
+	switch kind {
+	case "parameter":
+		if c.Parameters == nil {
+			c.Parameters = make(map[string]parameter)
+		}
+		return c.Parameters
+	case "response":
+		if c.Responses == nil {
+			c.Responses = make(map[string]response)
+		}
+		return c.Responses
+	case "requestBody":
+		if c.RequestBodies == nil {
+			c.RequestBodies = make(map[string]requestBody)
+		}
+		return c.RequestBodies
+	case "header":
+		if c.Headers == nil {
+			c.Headers = make(map[string]header)
+		}
+		return c.Headers
+	case "exampleObject":
+		if c.Examples == nil {
+			c.Examples = make(map[string]example)
+		}
+		return c.Examples
+	case "link":
+		if c.Links == nil {
+			c.Links = make(map[string]link)
+		}
+		return c.Links
+	case "securityScheme":
+		if c.SecuritySchemes == nil {
+			c.SecuritySchemes = make(map[string]securitySchemes)
+		}
+		return c.SecuritySchemes
+	case "callback":
+		if c.Callbacks == nil {
+			c.Callbacks = make(map[string]paths)
+		}
+		return c.Callbacks
+	}
+	return nil
+}
+
+// componentKinds are the kinds of reusable components, as named in annotations
+var componentKinds = []string{"parameter", "response", "requestBody", "header", "exampleObject", "link", "securityScheme", "callback"}
+
+// MergeComponents adds the reusable components of other, except the schemas.
+// It fails when a component already exists and is different.
+func (c *Components) MergeComponents(other Components) error {
+	for _, kind := range componentKinds {
+		m := c.components(kind)
+		om := reflect.ValueOf(other.components(kind))
+		for _, key := range om.MapKeys() {
+			if err := addComponent(m, key.String(), om.MapIndex(key).Interface()); err != nil {
+				return fmt.Errorf("%s %s: %w", kind, key.String(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// mapComponentsRefs calls mapSchemaRefs on every schema of the reusable
+// components
+func (spec *openAPI) mapComponentsRefs(fn func(string) string) {
+	c := &spec.Components
+	for _, p := range c.Parameters {
+		mapSchemaRefs(p.Schema, fn)
+		mapContentRefs(p.Content, fn)
+	}
+	for _, r := range c.Responses {
+		mapContentRefs(r.Content, fn)
+		mapHeadersRefs(r.Headers, fn)
+	}
+	for _, r := range c.RequestBodies {
+		mapContentRefs(r.Content, fn)
+	}
+	mapHeadersRefs(c.Headers, fn)
+	for _, cb := range c.Callbacks {
+		mapPathItemsRefs(cb, fn)
+	}
+}
+
+// addComponent adds a component to the map of components m, it fails when a
+// different component already exists with the same name
+func addComponent(m interface{}, name string, component interface{}) error {
+	mv := reflect.ValueOf(m)
+	key := reflect.ValueOf(name)
+	if current := mv.MapIndex(key); current.IsValid() {
+		if !reflect.DeepEqual(current.Interface(), component) {
+			return errors.New("component already exists and is different")
+		}
+		return nil
+	}
+	mv.SetMapIndex(key, reflect.ValueOf(component))
+	return nil
+}
+
+func (spec *openAPI) parseComponents(f *ast.File) (errs []error) {
+	for _, s := range f.Comments {
+		t := s.Text()
+		// Test if comment is a component
+		a := regexpComponent.FindStringSubmatch(t)
+		if len(a) == 0 {
+			continue
+		}
+		kind, name := a[1], a[2]
+
+		// Replacing tab with spaces
+		content := tab.ReplaceAllString(a[3], "  ")
+
+		m := spec.Components.components(kind)
+		component := reflect.New(reflect.TypeOf(m).Elem())
+		err := yaml.Unmarshal([]byte(content), component.Interface())
+		if err != nil {
+			logrus.
+				WithError(err).
+				WithField("content", content).
+				Errorf("Unable to unmarshal %s", kind)
+			errs = append(errs, &BuildError{
+				Err:     err,
+				Content: content,
+				Message: fmt.Sprintf("unable to unmarshal %s", kind),
+			})
+			continue
+		}
+
+		if err := addComponent(m, name, component.Elem().Interface()); err != nil {
+			logrus.
+				WithField("kind", kind).
+				WithField("name", name).
+				Error("Component already exists and is different")
+			errs = append(errs, &BuildError{
+				Err:     err,
+				Content: fmt.Sprintf("%s: %s", kind, name),
+			})
+			continue
+		}
+
+		logrus.
+			WithField("kind", kind).
+			WithField("name", name).
+			Info("Parsing component")
+	}
+	return
+}
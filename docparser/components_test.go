@@ -0,0 +1,142 @@
+package docparser
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const componentsSource = `package test
+
+// @openapi:parameter Limit
+//	in: query
+//	name: limit
+//	description: Maximum number of items
+//	schema:
+//		type: integer
+var limit int
+
+// @openapi:response NotFound
+//	description: The resource was not found
+//	content:
+//		application/json:
+//			schema:
+//				$ref: "#/components/schemas/Error"
+var notFound int
+
+// @openapi:header RateLimit
+//	description: Remaining requests
+//	schema:
+//		type: integer
+
+// @openapi:requestBody Pet
+//	required: true
+//	content:
+//		application/json:
+//			schema:
+//				$ref: "#/components/schemas/Pet"
+
+// @openapi:exampleObject Rex
+//	summary: A dog
+//	value:
+//		name: Rex
+
+// @openapi:link PetOwner
+//	operationId: GetOwner
+//	parameters:
+//		id: $response.body#/owner
+
+// @openapi:path
+// /pets:
+//	post:
+//		requestBody:
+//			$ref: "#/components/requestBodies/Pet"
+//		parameters:
+//			- $ref: "#/components/parameters/Limit"
+//		responses:
+//			"201":
+//				description: Created
+//				headers:
+//					X-Rate-Limit:
+//						$ref: "#/components/headers/RateLimit"
+//			"404":
+//				$ref: "#/components/responses/NotFound"
+func Post() {}
+`
+
+func TestParseComponents(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "", componentsSource, parser.ParseComments)
+	assert.NoError(t, err)
+
+	spec := NewOpenAPI()
+	assert.Empty(t, spec.parseComponents(f))
+	assert.Empty(t, spec.parsePaths(f))
+
+	assert.Equal(t, "limit", spec.Components.Parameters["Limit"].Name)
+	assert.Equal(t, "integer", spec.Components.Parameters["Limit"].Schema.Type)
+	assert.Equal(t, "#/components/schemas/Error", spec.Components.Responses["NotFound"].Content["application/json"].Schema.Ref)
+	assert.Equal(t, "Remaining requests", spec.Components.Headers["RateLimit"].Description)
+	assert.True(t, spec.Components.RequestBodies["Pet"].Required)
+	assert.Equal(t, "A dog", spec.Components.Examples["Rex"].Summary)
+	assert.Equal(t, "GetOwner", spec.Components.Links["PetOwner"].OperationID)
+
+	b, err := yaml.Marshal(spec.Paths["/pets"].Operations["post"])
+	assert.NoError(t, err)
+	assert.Equal(t, `responses:
+  "201":
+    description: Created
+    headers:
+      X-Rate-Limit:
+        $ref: '#/components/headers/RateLimit'
+  "404":
+    $ref: '#/components/responses/NotFound'
+parameters:
+- $ref: '#/components/parameters/Limit'
+requestBody:
+  $ref: '#/components/requestBodies/Pet'
+`, string(b))
+}
+
+func TestParseComponentsDuplicated(t *testing.T) {
+	src := `package test
+
+// @openapi:parameter Limit
+//	in: query
+//	name: limit
+
+// @openapi:parameter Limit
+//	in: query
+//	name: limit
+
+// @openapi:parameter Limit
+//	in: query
+//	name: max
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+	assert.NoError(t, err)
+
+	spec := NewOpenAPI()
+	errs := spec.parseComponents(f)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "limit", spec.Components.Parameters["Limit"].Name)
+}
+
+func TestMergeComponents(t *testing.T) {
+	main := Components{Parameters: map[string]parameter{"Limit": {In: "query", Name: "limit"}}}
+
+	err := main.MergeComponents(Components{
+		Parameters: map[string]parameter{"Limit": {In: "query", Name: "limit"}},
+		Responses:  map[string]response{"NotFound": {Description: "Not found"}},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, main.Parameters, 1)
+	assert.Len(t, main.Responses, 1)
+
+	err = main.MergeComponents(Components{
+		Parameters: map[string]parameter{"Limit": {In: "query", Name: "max"}},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,322 @@
+package docparser
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// ConfigFile is the name of the configuration file, it's looked for in the
+// parsed folder and its parents
+const ConfigFile = ".openapi-parser.yaml"
+
+// regexpTypeName matches the name of a type, with its package or not
+var regexpTypeName = regexp.MustCompile(`^(\w+\.)?\w+$`)
+
+// Config is the configuration of the parser
+type Config struct {
+	// Paths are the folders to parse
+	Paths []string `yaml:"paths,omitempty"`
+	// Modules are the modules to parse, see SetModules
+	Modules []string `yaml:"modules,omitempty"`
+	// Vendors are the vendored packages to parse
+	Vendors     []string `yaml:"vendors,omitempty"`
+	VendorsPath string   `yaml:"vendorsPath,omitempty"`
+	Output      string   `yaml:"output,omitempty"`
+	// Format is the format of the output, yaml or json
+	Format string `yaml:"format,omitempty"`
+	// Include are the patterns of the files parsed, every file by default
+	Include []string `yaml:"include,omitempty"`
+	// Exclude are the patterns of the files and folders which aren't parsed
+	Exclude []string `yaml:"exclude,omitempty"`
+	// Tags are the build tags satisfied by the files parsed
+	Tags                  []string `yaml:"tags,omitempty"`
+	ExitError             bool     `yaml:"exitError,omitempty"`
+	GenericNameTemplate   string   `yaml:"genericNameTemplate,omitempty"`
+	HoistAnonymousStructs bool     `yaml:"hoistAnonymousStructs,omitempty"`
+	// FillExamples fills the examples of the schemas and of the media types
+	// which have none, see FillExamples
+	FillExamples bool `yaml:"fillExamples,omitempty"`
+	// Jobs is the number of files parsed concurrently, the number of CPUs by
+	// default
+	Jobs int `yaml:"jobs,omitempty"`
+	// CacheDir is the folder of the cache, see SetCacheDir, the folder of
+	// DefaultCacheDir by default
+	CacheDir string `yaml:"cacheDir,omitempty"`
+	NoCache  bool   `yaml:"noCache,omitempty"`
+	// Types are the schemas of the Go types, by name, i.e. uuid.UUID
+	Types map[string]*schema `yaml:"types,omitempty"`
+	// DefaultResponses are the responses added to every operation, by status
+	// code, see SetDefaultResponses
+	DefaultResponses map[string]string `yaml:"defaultResponses,omitempty"`
+	// Info overrides the fields of the info of the document
+	Info *info `yaml:"info,omitempty"`
+	// Servers are the servers of the document by environment, the servers of
+	// Env replace the ones of the annotations
+	Servers map[string][]server `yaml:"servers,omitempty"`
+	Env     string              `yaml:"env,omitempty"`
+}
+
+// DefaultConfig returns the configuration used when neither the file nor the
+// flags set a value
+func DefaultConfig() Config {
+	return Config{
+		Paths:               []string{"."},
+		VendorsPath:         "vendor",
+		Output:              "openapi.yaml",
+		Format:              "yaml",
+		GenericNameTemplate: DefaultGenericNameTemplate,
+	}
+}
+
+// FindConfig returns the path of the configuration file found in dir or in
+// its parents, it's empty when there is none
+func FindConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		p := filepath.Join(dir, ConfigFile)
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// LoadConfig reads the configuration file over the config c. The relative
+// paths of the file are relative to its folder.
+func LoadConfig(path string, c Config) (Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+
+	loaded := Config{}
+	if err := yaml.UnmarshalStrict(data, &loaded); err != nil {
+		return c, fmt.Errorf("%s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	relative := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+	for i, p := range loaded.Paths {
+		loaded.Paths[i] = relative(p)
+	}
+	loaded.VendorsPath = relative(loaded.VendorsPath)
+	loaded.Output = relative(loaded.Output)
+	loaded.CacheDir = relative(loaded.CacheDir)
+
+	return c.Override(loaded), nil
+}
+
+// Override returns the config c with the values set in other
+func (c Config) Override(other Config) Config {
+	if len(other.Paths) > 0 {
+		c.Paths = other.Paths
+	}
+	if len(other.Modules) > 0 {
+		c.Modules = other.Modules
+	}
+	if len(other.Vendors) > 0 {
+		c.Vendors = other.Vendors
+	}
+	if other.VendorsPath != "" {
+		c.VendorsPath = other.VendorsPath
+	}
+	if other.Output != "" {
+		c.Output = other.Output
+	}
+	if other.Format != "" {
+		c.Format = other.Format
+	}
+	if len(other.Include) > 0 {
+		c.Include = other.Include
+	}
+	if len(other.Exclude) > 0 {
+		c.Exclude = other.Exclude
+	}
+	if len(other.Tags) > 0 {
+		c.Tags = other.Tags
+	}
+	c.ExitError = c.ExitError || other.ExitError
+	if other.GenericNameTemplate != "" {
+		c.GenericNameTemplate = other.GenericNameTemplate
+	}
+	c.HoistAnonymousStructs = c.HoistAnonymousStructs || other.HoistAnonymousStructs
+	c.FillExamples = c.FillExamples || other.FillExamples
+	if other.Jobs > 0 {
+		c.Jobs = other.Jobs
+	}
+	if other.CacheDir != "" {
+		c.CacheDir = other.CacheDir
+	}
+	c.NoCache = c.NoCache || other.NoCache
+	if len(other.Types) > 0 {
+		c.Types = other.Types
+	}
+	// the default responses are overridden by status code
+	if len(other.DefaultResponses) > 0 {
+		responses := make(map[string]string, len(c.DefaultResponses)+len(other.DefaultResponses))
+		for code, ref := range c.DefaultResponses {
+			responses[code] = ref
+		}
+		for code, ref := range other.DefaultResponses {
+			responses[code] = ref
+		}
+		c.DefaultResponses = responses
+	}
+	if other.Info != nil {
+		i := info{}
+		if c.Info != nil {
+			i = *c.Info
+		}
+		i.override(*other.Info)
+		c.Info = &i
+	}
+	if len(other.Servers) > 0 {
+		c.Servers = other.Servers
+	}
+	if other.Env != "" {
+		c.Env = other.Env
+	}
+	return c
+}
+
+// cacheDir returns the folder of the cache
+func (c Config) cacheDir() (string, error) {
+	if c.CacheDir != "" {
+		return c.CacheDir, nil
+	}
+	return DefaultCacheDir()
+}
+
+// CleanCache removes the folder of the cache
+func (c Config) CleanCache() error {
+	dir, err := c.cacheDir()
+	if err != nil {
+		return err
+	}
+	return CleanCache(dir)
+}
+
+// Configure applies the configuration to the document, before parsing
+func (spec *openAPI) Configure(c Config) error {
+	if err := spec.SetGenericNameTemplate(c.GenericNameTemplate); err != nil {
+		return err
+	}
+	spec.SetHoistAnonymousStructs(c.HoistAnonymousStructs)
+	spec.SetFillExamples(c.FillExamples)
+	if err := spec.SetDefaultResponses(c.DefaultResponses); err != nil {
+		return err
+	}
+	if err := spec.SetInclude(c.Include); err != nil {
+		return err
+	}
+	if err := spec.SetExclude(c.Exclude); err != nil {
+		return err
+	}
+	spec.SetTags(c.Tags)
+	spec.SetJobs(c.Jobs)
+	if !c.NoCache {
+		dir, err := c.cacheDir()
+		if err != nil {
+			logrus.WithError(err).Warn("The cache is disabled, no cache folder")
+		}
+		spec.SetCacheDir(dir)
+	}
+	if len(c.Paths) > 0 {
+		if err := spec.SetModules(c.Paths[0], c.Modules); err != nil {
+			return err
+		}
+	}
+	if err := spec.SetTypes(c.Types); err != nil {
+		return err
+	}
+	if c.Info != nil && c.Info.License != nil && c.Info.License.Identifier != "" && c.Info.License.URL != "" {
+		return errors.New("the identifier and the url of the info license are mutually exclusive")
+	}
+	spec.infoOverride = c.Info
+
+	if len(c.Servers) > 0 && c.Env != "" {
+		servers, ok := c.Servers[c.Env]
+		if !ok {
+			return fmt.Errorf("no servers for the environment %q", c.Env)
+		}
+		spec.serversOverride = servers
+	} else if len(c.Servers) > 0 {
+		// the servers of every environment, by name of environment
+		envs := make([]string, 0, len(c.Servers))
+		for env := range c.Servers {
+			envs = append(envs, env)
+		}
+		sort.Strings(envs)
+		spec.serversOverride = []server{}
+		for _, env := range envs {
+			spec.serversOverride = append(spec.serversOverride, c.Servers[env]...)
+		}
+	}
+	return nil
+}
+
+// SetTypes sets the schemas of Go types, i.e. uuid.UUID or decimal.Decimal,
+// registered in place of the schemas of the types with the same name
+func (spec *openAPI) SetTypes(types map[string]*schema) error {
+	for name, s := range types {
+		if s == nil {
+			return fmt.Errorf("type %s requires a schema", name)
+		}
+		if !regexpTypeName.MatchString(name) {
+			return fmt.Errorf("invalid type name %q", name)
+		}
+	}
+	spec.types = types
+	return nil
+}
+
+// registerTypes registers the schemas of the configured types, the refs to a
+// type use the name of the type without its package
+func (spec *openAPI) registerTypes() {
+	for name, s := range spec.types {
+		short := name[strings.LastIndex(name, ".")+1:]
+		if _, ok := spec.registeredSchemas[short]; ok {
+			logrus.
+				WithField("type", name).
+				Warn("Schema already exists, the configured type replaces it")
+		}
+		registered := *s
+		registered.metadata.RealName = short
+		spec.registeredSchemas[short] = &registered
+	}
+}
+
+// applyOverrides replaces the info fields and the servers of the document
+// with the configured ones
+func (spec *openAPI) applyOverrides() {
+	if spec.infoOverride != nil {
+		spec.Info.override(*spec.infoOverride)
+	}
+	if spec.serversOverride != nil {
+		spec.Servers = append([]server{}, spec.serversOverride...)
+	}
+}
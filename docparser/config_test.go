@@ -0,0 +1,131 @@
+package docparser
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `paths: [api]
+output: docs/openapi.json
+format: json
+exclude: [legacy, "*_gen.go"]
+types:
+  uuid.UUID:
+    type: string
+    format: uuid
+defaultResponses:
+  "500": ErrorResponse
+info:
+  title: Pets
+  contact:
+    email: api@example.com
+servers:
+  production:
+    - url: https://api.example.com
+  staging:
+    - url: https://staging.example.com
+`
+
+func TestFindConfig(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "api", "handlers")
+	assert.NoError(t, os.MkdirAll(sub, 0755))
+
+	file, err := FindConfig(sub)
+	assert.NoError(t, err)
+	assert.Empty(t, file)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ConfigFile), []byte(testConfig), 0644))
+	file, err = FindConfig(sub)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, ConfigFile), file)
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, ConfigFile)
+	assert.NoError(t, ioutil.WriteFile(file, []byte(testConfig), 0644))
+
+	c, err := LoadConfig(file, DefaultConfig())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "api")}, c.Paths)
+	assert.Equal(t, filepath.Join(dir, "docs/openapi.json"), c.Output)
+	assert.Equal(t, "vendor", c.VendorsPath)
+	assert.Equal(t, "json", c.Format)
+	assert.Equal(t, DefaultGenericNameTemplate, c.GenericNameTemplate)
+	assert.Equal(t, &schema{Type: "string", Format: "uuid"}, c.Types["uuid.UUID"])
+	assert.Equal(t, "Pets", c.Info.Title)
+	assert.Len(t, c.Servers, 2)
+
+	// the flags override the file, the default responses by status code
+	c = c.Override(Config{
+		Output:           "openapi.yaml",
+		DefaultResponses: map[string]string{"404": "ErrorResponse"},
+	})
+	assert.Equal(t, "openapi.yaml", c.Output)
+	assert.Equal(t, map[string]string{"404": "ErrorResponse", "500": "ErrorResponse"}, c.DefaultResponses)
+
+	assert.NoError(t, ioutil.WriteFile(file, []byte("paths: [api]\nunknown: true\n"), 0644))
+	_, err = LoadConfig(file, DefaultConfig())
+	assert.Error(t, err)
+}
+
+func TestConfigure(t *testing.T) {
+	c := DefaultConfig()
+	c.Servers = map[string][]server{
+		"production": {{URL: "https://api.example.com"}},
+		"staging":    {{URL: "https://staging.example.com"}},
+	}
+	c.Info = &info{Title: "Pets", Contact: &contact{Email: "api@example.com"}}
+
+	spec := NewOpenAPI()
+	spec.Info = info{Title: "Animals", Version: "1.0.0"}
+	spec.Servers = []server{{URL: "http://localhost"}}
+	assert.NoError(t, spec.Configure(c))
+	spec.applyOverrides()
+	assert.Equal(t, info{Title: "Pets", Version: "1.0.0", Contact: &contact{Email: "api@example.com"}}, spec.Info)
+	assert.Equal(t, []server{{URL: "https://api.example.com"}, {URL: "https://staging.example.com"}}, spec.Servers)
+
+	c.Env = "staging"
+	spec = NewOpenAPI()
+	assert.NoError(t, spec.Configure(c))
+	spec.applyOverrides()
+	assert.Equal(t, []server{{URL: "https://staging.example.com"}}, spec.Servers)
+
+	c.Env = "test"
+	assert.Error(t, spec.Configure(c))
+
+	c = DefaultConfig()
+	c.Info = &info{License: &license{Identifier: "MIT"}}
+	spec = NewOpenAPI()
+	spec.Info = info{License: &license{Name: "MIT", URL: "https://opensource.org/licenses/MIT"}}
+	assert.NoError(t, spec.Configure(c))
+	spec.applyOverrides()
+	assert.Equal(t, &license{Name: "MIT", Identifier: "MIT"}, spec.Info.License)
+
+	c.Info.License.URL = "https://opensource.org/licenses/MIT"
+	assert.Error(t, spec.Configure(c))
+
+	c = DefaultConfig()
+	c.Exclude = []string{"[invalid"}
+	assert.Error(t, spec.Configure(c))
+
+	c = DefaultConfig()
+	c.Types = map[string]*schema{"uuid UUID": {Type: "string"}}
+	assert.Error(t, spec.Configure(c))
+}
+
+func TestRegisterTypes(t *testing.T) {
+	spec := NewOpenAPI()
+	assert.NoError(t, spec.SetTypes(map[string]*schema{"uuid.UUID": {Type: "string", Format: "uuid"}}))
+	spec.registerTypes()
+
+	s, ok := spec.registeredSchemas["UUID"].(*schema)
+	assert.True(t, ok)
+	assert.Equal(t, "uuid", s.Format)
+	assert.Equal(t, "UUID", s.RealName())
+}
@@ -1,42 +1,223 @@
 package docparser
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
-	"go/ast"
+	"go/token"
+	"math"
+	"reflect"
 	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
 )
 
-func convertExample(example string, exampleType ast.Expr) (interface{}, error) {
-	expr, ok := exampleType.(*ast.Ident)
-	if !ok {
-		return example, nil
+var schemaType = reflect.TypeOf(&schema{})
+
+// convertExample converts an example to the type of its schema. The example
+// of a string is taken as it is, the others are read as json, i.e. [1, 2] or
+// {"name": "Rex"}, and an example which isn't json is a string. The schemas
+// referenced aren't known yet, the example is converted again once they are,
+// see checkExamples.
+func convertExample(example string, s *schema) interface{} {
+	if s.Ref == "" && s.Type == "string" {
+		var str string
+		if strings.HasPrefix(example, `"`) && json.Unmarshal([]byte(example), &str) == nil {
+			return str
+		}
+		return example
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(example), &value); err != nil {
+		return example
+	}
+	return convertValue(nil, s, value, 0)
+}
+
+// convertValue converts a value decoded from json or yaml to the type of its
+// schema: the integers are int64, the numbers float64, and the scalars given
+// to a string are formatted. The values which can't be converted are kept,
+// they don't validate.
+func convertValue(schemas map[string]*schema, s *schema, value interface{}, depth int) interface{} {
+	if s == nil || depth > 32 {
+		return value
+	}
+	if s.Ref != "" {
+		if target, ok := schemas[refName(s.Ref)]; ok {
+			return convertValue(schemas, target, value, depth+1)
+		}
+		return value
+	}
+	for _, part := range s.AllOf {
+		value = convertValue(schemas, part, value, depth+1)
+	}
+
+	switch v := normalizeValue(value).(type) {
+	case float64:
+		switch s.Type {
+		case "integer":
+			if v == math.Trunc(v) && math.Abs(v) < 1<<63 {
+				return int64(v)
+			}
+		case "string":
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		return v
+	case bool:
+		if s.Type == "string" {
+			return strconv.FormatBool(v)
+		}
+	case string:
+		switch s.Type {
+		case "integer":
+			if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return i
+			}
+		case "number":
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return f
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b
+			}
+		}
+	case []interface{}:
+		if s.Type == "string" {
+			return compactJSON(v)
+		}
+		for i, item := range v {
+			v[i] = convertValue(schemas, s.Items, item, depth+1)
+		}
+		return v
+	case map[string]interface{}:
+		if s.Type == "string" {
+			return compactJSON(v)
+		}
+		for k, item := range v {
+			if p, ok := s.Properties[k]; ok {
+				v[k] = convertValue(schemas, p, item, depth+1)
+			} else if s.AdditionalProperties != nil {
+				v[k] = convertValue(schemas, s.AdditionalProperties, item, depth+1)
+			}
+		}
+		return v
+	}
+	return value
+}
+
+// examplePositions returns the positions of the @openapi:example annotations
+// of the schemas of an entity by their path in the entity, for the cache
+func (spec *openAPI) examplePositions(entity interface{}) map[string]string {
+	var positions map[string]string
+	walkSchemaPaths(reflect.ValueOf(entity), "", func(path string, s *schema) {
+		if !s.examplePos.IsValid() {
+			return
+		}
+		if positions == nil {
+			positions = make(map[string]string)
+		}
+		positions[path] = spec.fset.Position(s.examplePos).String()
+	})
+	return positions
+}
+
+// setExamplePositions sets the positions of the annotations of the schemas of
+// an entity read from the cache, see examplePositions
+func setExamplePositions(entity interface{}, positions map[string]string) {
+	if len(positions) == 0 {
+		return
 	}
+	walkSchemaPaths(reflect.ValueOf(entity), "", func(path string, s *schema) {
+		if at, ok := positions[path]; ok {
+			s.exampleAt = at
+		}
+	})
+}
 
-	switch expr.Name {
-	case "int", "int8", "int32", "int64":
-		i, err := strconv.ParseInt(example, 10, 64)
-		if err != nil {
-			return nil, fmt.Errorf("could not parse int: %w", err)
+// checkExamples converts the examples of the annotations to the type of their
+// schema, the references resolved, and reports the ones which don't match
+// their schema at the position of their annotation
+func (spec *openAPI) checkExamples() (errs []error) {
+	schemas, err := spec.componentSchemas()
+	if err != nil {
+		return []error{err}
+	}
+	v := validator{schemas: schemas}
+
+	walkSchemas(reflect.ValueOf(spec), func(s *schema) {
+		at := s.exampleAt
+		if at == "" && s.examplePos.IsValid() {
+			at = spec.fset.Position(s.examplePos).String()
+		}
+		if at == "" {
+			return
 		}
-		return i, nil
+		// a schema found twice is checked once
+		s.exampleAt, s.examplePos = "", token.NoPos
 
-	case "uint", "uint8", "uint32", "uint64":
-		u, err := strconv.ParseUint(example, 10, 64)
-		if err != nil {
-			return nil, fmt.Errorf("could not parse uint: %w", err)
+		s.Example = convertValue(schemas, s, s.Example, 0)
+		if reasons := v.validate(s, s.Example, "example"); len(reasons) > 0 {
+			err := errors.New(strings.Join(reasons, ", "))
+			logrus.
+				WithError(err).
+				WithField("content", at).
+				Error("example doesn't match the schema")
+			errs = append(errs, &BuildError{
+				Err:     err,
+				Content: at,
+				Message: "example doesn't match the schema",
+			})
 		}
+	})
+	return errs
+}
+
+// walkSchemas calls fn with every schema of v, and of every object it
+// contains
+func walkSchemas(v reflect.Value, fn func(*schema)) {
+	walkSchemaPaths(v, "", func(_ string, s *schema) {
+		fn(s)
+	})
+}
+
+// walkSchemaPaths calls fn with every schema of v and its path in v, the
+// fields and the keys separated by dots, i.e. Properties.tags.Items
+func walkSchemaPaths(v reflect.Value, path string, fn func(string, *schema)) {
+	join := func(elem string) string {
+		if path == "" {
+			return elem
+		}
+		return path + "." + elem
+	}
 
-		return u, nil
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		if v.Type() == schemaType {
+			fn(path, v.Interface().(*schema))
+		}
+		walkSchemaPaths(v.Elem(), path, fn)
 
-	case "float", "float32", "float64":
-		f, err := strconv.ParseFloat(example, 64)
-		if err != nil {
-			return nil, fmt.Errorf("could not parse float: %w", err)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Type().Field(i); f.PkgPath == "" {
+				walkSchemaPaths(v.Field(i), join(f.Name), fn)
+			}
 		}
 
-		return f, nil
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			walkSchemaPaths(v.MapIndex(k), join(fmt.Sprint(k.Interface())), fn)
+		}
 
-	default:
-		return example, nil
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walkSchemaPaths(v.Index(i), join(strconv.Itoa(i)), fn)
+		}
 	}
 }
@@ -0,0 +1,156 @@
+package docparser
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertExample(t *testing.T) {
+	tests := []struct {
+		name     string
+		example  string
+		schema   *schema
+		expected interface{}
+	}{
+		{name: "integer", example: "42", schema: &schema{Type: "integer"}, expected: int64(42)},
+		{name: "number", example: "4.2", schema: &schema{Type: "number"}, expected: 4.2},
+		{name: "boolean", example: "true", schema: &schema{Type: "boolean"}, expected: true},
+		{name: "string", example: "Some String", schema: &schema{Type: "string"}, expected: "Some String"},
+		{name: "string of a number", example: "42", schema: &schema{Type: "string"}, expected: "42"},
+		{name: "quoted string", example: `"a b"`, schema: &schema{Type: "string"}, expected: "a b"},
+		{name: "not json", example: "Rex", schema: &schema{Type: "integer"}, expected: "Rex"},
+		{
+			name:     "array",
+			example:  `["a", "b"]`,
+			schema:   &schema{Type: "array", Items: &schema{Type: "string"}},
+			expected: []interface{}{"a", "b"},
+		},
+		{
+			name:     "array of integers",
+			example:  `[1, 2]`,
+			schema:   &schema{Type: "array", Items: &schema{Type: "integer"}},
+			expected: []interface{}{int64(1), int64(2)},
+		},
+		{
+			name:     "map",
+			example:  `{"x": 1}`,
+			schema:   &schema{Type: "object", AdditionalProperties: &schema{Type: "integer"}},
+			expected: map[string]interface{}{"x": int64(1)},
+		},
+		{
+			name:    "object",
+			example: `{"name": "Rex", "age": 3, "weight": 4.5}`,
+			schema: &schema{Type: "object", Properties: map[string]*schema{
+				"name":   {Type: "string"},
+				"age":    {Type: "integer"},
+				"weight": {Type: "number"},
+			}},
+			expected: map[string]interface{}{"name": "Rex", "age": int64(3), "weight": 4.5},
+		},
+		{
+			name:     "reference",
+			example:  `{"age": 3}`,
+			schema:   &schema{Ref: "#/components/schemas/Pet"},
+			expected: map[string]interface{}{"age": float64(3)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, convertExample(tt.example, tt.schema))
+		})
+	}
+}
+
+func TestCheckExamples(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pets.go")
+	writeFiles(t, map[string]string{
+		path: `package pets
+
+// @openapi:example {"name": "Rex", "age": 3}
+// @openapi:schema
+type Pet struct {
+	// @openapi:example Rex
+	Name string ` + "`json:\"name\"`" + `
+	// @openapi:example 3
+	Age *int ` + "`json:\"age\"`" + `
+	// @openapi:example true
+	Vaccinated bool ` + "`json:\"vaccinated\"`" + `
+	// @openapi:example ["small", "brown"]
+	Tags []string ` + "`json:\"tags\"`" + `
+	// @openapi:example {"x": 1}
+	Scores map[string]int ` + "`json:\"scores\"`" + `
+	// @openapi:example {"name": "Felix"}
+	Parent *Pet ` + "`json:\"parent\"`" + `
+	// @openapi:example bird
+	Kind string ` + "`json:\"kind\" validate:\"oneof=cat dog\"`" + `
+	// @openapi:example three
+	Legs int ` + "`json:\"legs\"`" + `
+	// @openapi:example 2
+	Size int ` + "`json:\"size\" validate:\"oneof=1 2\"`" + `
+	// @openapi:example 3
+	Rank int ` + "`json:\"rank\" validate:\"oneof=1 2\"`" + `
+}
+`,
+	})
+
+	for _, cacheDir := range []string{"", filepath.Join(dir, "cache"), filepath.Join(dir, "cache")} {
+		spec := NewOpenAPI()
+		spec.SetCacheDir(cacheDir)
+		spec.Parse([]string{dir}, nil, "vendor", false)
+
+		pet := spec.Components.Schemas["Pet"].(*schema)
+		assert.Equal(t, map[string]interface{}{"name": "Rex", "age": int64(3)}, pet.Example)
+		assert.Equal(t, "Rex", pet.Properties["name"].Example)
+		assert.Equal(t, int64(3), pet.Properties["age"].Example)
+		assert.Equal(t, true, pet.Properties["vaccinated"].Example)
+		assert.Equal(t, []interface{}{"small", "brown"}, pet.Properties["tags"].Example)
+		assert.Equal(t, map[string]interface{}{"x": int64(1)}, pet.Properties["scores"].Example)
+		assert.Equal(t, map[string]interface{}{"name": "Felix"}, pet.Properties["parent"].Example)
+		assert.Equal(t, int64(2), pet.Properties["size"].Example)
+		assert.Equal(t, []interface{}{1.0, 2.0}, normalizeValue(pet.Properties["size"].Enum))
+		assert.Empty(t, pet.Properties["name"].exampleAt)
+
+		var errs []string
+		for _, err := range spec.Errors() {
+			be := err.(*BuildError)
+			assert.Equal(t, "example doesn't match the schema", be.Message)
+			errs = append(errs, strings.TrimPrefix(be.Content, path)+" "+be.Err.Error())
+		}
+		assert.ElementsMatch(t, []string{
+			`:18:5 example must be one of ["cat","dog"], got "bird"`,
+			`:20:5 example must be an integer, got "three"`,
+			`:24:5 example must be one of [1,2], got 3`,
+		}, errs)
+	}
+}
+
+func TestCheckExamplesBounds(t *testing.T) {
+	min, max := 0.0, 30.0
+	spec := NewOpenAPI()
+	spec.Components.Schemas["Age"] = &schema{Type: "integer", Minimum: &min, Maximum: &max}
+	spec.Components.Schemas["Pet"] = &schema{Type: "object", Properties: map[string]*schema{
+		"age": {Ref: "#/components/schemas/Age", Example: 42, exampleAt: "pets.go:4:2"},
+		"ages": {
+			Type:      "array",
+			Items:     &schema{Ref: "#/components/schemas/Age"},
+			Example:   []interface{}{1, -1},
+			exampleAt: "pets.go:6:2",
+		},
+	}}
+
+	errs := spec.checkExamples()
+	assert.Len(t, errs, 2)
+	assert.ElementsMatch(t, []string{
+		"pets.go:4:2 example must be <= 30",
+		"pets.go:6:2 example[1] must be >= 0",
+	}, []string{
+		errs[0].(*BuildError).Content + " " + errs[0].(*BuildError).Err.Error(),
+		errs[1].(*BuildError).Content + " " + errs[1].(*BuildError).Err.Error(),
+	})
+	assert.Empty(t, spec.Components.Schemas["Pet"].(*schema).Properties["age"].exampleAt)
+}
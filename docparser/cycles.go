@@ -0,0 +1,115 @@
+package docparser
+
+import (
+	"go/ast"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxGenericDepth is the maximum nesting of the type arguments of an
+// instantiated generic type, deeper instantiations come from an instantiation
+// cycle like Tree[T] having a field of type Tree[[]T]
+const maxGenericDepth = 16
+
+// genericDepth returns the nesting of slices, maps and generic types in a type
+// expression
+func genericDepth(expr ast.Expr) int {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return genericDepth(e.X)
+	case *ast.ArrayType:
+		return genericDepth(e.Elt) + 1
+	case *ast.MapType:
+		k, v := genericDepth(e.Key), genericDepth(e.Value)
+		if k > v {
+			return k + 1
+		}
+		return v + 1
+	case *ast.IndexExpr, *ast.IndexListExpr:
+		_, args := genericInstanceParts(e)
+		max := 0
+		for _, a := range args {
+			if d := genericDepth(a); d > max {
+				max = d
+			}
+		}
+		return max + 1
+	}
+	return 0
+}
+
+// compositionRef returns the name of the schema an allOf member refers to
+func compositionRef(s *schema) string {
+	if s == nil || !strings.HasPrefix(s.Ref, schemaRefPrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(s.Ref, schemaRefPrefix)
+}
+
+// breakCompositionCycles removes the cycles created by self embedding or
+// mutually embedded structs, i.e. A embeds *B and B embeds *A.
+// Like encoding/json, which skips an embedded struct already visited, the
+// member of allOf closing a cycle is replaced by the members of the schema it
+// refers to which don't lead back into the cycle.
+func (spec *openAPI) breakCompositionCycles() {
+	names := make([]string, 0, len(spec.registeredSchemas))
+	for name := range spec.registeredSchemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	done := make(map[string]bool)
+	stack := make(map[string]bool)
+
+	var visit func(name string)
+	visit = func(name string) {
+		if done[name] {
+			return
+		}
+		cs, ok := spec.registeredSchemas[name].(*composedSchema)
+		if !ok {
+			done[name] = true
+			return
+		}
+
+		stack[name] = true
+		allOf := make([]*schema, 0, len(cs.AllOf))
+		for _, member := range cs.AllOf {
+			ref := compositionRef(member)
+			if !stack[ref] {
+				if ref != "" {
+					visit(ref)
+				}
+				allOf = append(allOf, member)
+				continue
+			}
+
+			logrus.
+				WithField("schema", name).
+				WithField("embedded", ref).
+				Warn("Breaking composition cycle")
+
+			if ref == name {
+				// self embedding doesn't add any field
+				continue
+			}
+			target := spec.registeredSchemas[ref].(*composedSchema)
+			for _, m := range target.AllOf {
+				if stack[compositionRef(m)] {
+					continue
+				}
+				c := *m
+				allOf = append(allOf, &c)
+			}
+		}
+		cs.AllOf = allOf
+		delete(stack, name)
+		done[name] = true
+	}
+
+	for _, name := range names {
+		visit(name)
+	}
+}
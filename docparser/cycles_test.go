@@ -0,0 +1,143 @@
+package docparser
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+type recursiveTypeTestCase struct {
+	description    string
+	schemaName     string
+	expectedSchema string
+}
+
+func TestParseRecursiveTypes(t *testing.T) {
+	f, err := parseFile("datatest/recursive.go")
+	assert.NoError(t, err)
+
+	spec := NewOpenAPI()
+	assert.Empty(t, spec.parseSchemas(f))
+	assert.Empty(t, spec.instantiateGenerics())
+	spec.composeSpecSchemas()
+
+	testCases := []recursiveTypeTestCase{
+		{
+			description: "Should use a $ref for a recursive struct",
+			schemaName:  "Node",
+			expectedSchema: `type: object
+properties:
+  children:
+    type: array
+    items:
+      $ref: '#/components/schemas/Node'
+  index:
+    type: object
+    additionalProperties:
+      $ref: '#/components/schemas/Node'
+  name:
+    type: string
+  parent:
+    $ref: '#/components/schemas/Node'
+`,
+		},
+		{
+			description: "Should use a $ref for mutually recursive structs",
+			schemaName:  "Manager",
+			expectedSchema: `type: object
+properties:
+  name:
+    type: string
+  reports:
+    type: array
+    items:
+      $ref: '#/components/schemas/Employee'
+`,
+		},
+		{
+			description: "Should drop a self embedded struct",
+			schemaName:  "SelfEmbedded",
+			expectedSchema: `allOf:
+- type: object
+  properties:
+    name:
+      type: string
+`,
+		},
+		{
+			description: "Should keep the first embedding of mutually embedded structs",
+			schemaName:  "Left",
+			expectedSchema: `allOf:
+- $ref: '#/components/schemas/Right'
+- type: object
+  properties:
+    left_name:
+      type: string
+`,
+		},
+		{
+			description: "Should inline the fields of the struct closing an embedding cycle",
+			schemaName:  "Right",
+			expectedSchema: `allOf:
+- type: object
+  properties:
+    left_name:
+      type: string
+- type: object
+  properties:
+    right_name:
+      type: string
+`,
+		},
+		{
+			description: "Should use a $ref for a recursive generic type",
+			schemaName:  "TreeNode",
+			expectedSchema: `type: object
+properties:
+  children:
+    type: array
+    items:
+      $ref: '#/components/schemas/TreeNode'
+  parent:
+    $ref: '#/components/schemas/TreeNode'
+  value:
+    $ref: '#/components/schemas/Node'
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			s, ok := spec.Components.Schemas[tc.schemaName]
+			assert.True(t, ok)
+			b, err := yaml.Marshal(s)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedSchema, string(b))
+		})
+	}
+}
+
+func TestInstantiateGenericsCycle(t *testing.T) {
+	src := `package test
+
+type Nested[T any] struct {
+	Value T
+	Next  *Nested[[]T]
+}
+
+// @openapi:schema
+type Root struct {
+	Nested Nested[string] ` + "`json:\"nested\"`" + `
+}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+	assert.NoError(t, err)
+
+	spec := NewOpenAPI()
+	spec.parseSchemas(f)
+	errs := spec.instantiateGenerics()
+	assert.Len(t, errs, 1)
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import "mime/multipart"
+
+// UploadPhoto is the form uploading the photos of a pet
+type UploadPhoto struct {
+	// Name of the photo
+	Name string `form:"name" binding:"required"`
+	// @openapi:contentType image/png, image/jpeg
+	Photo     *multipart.FileHeader   `form:"photo" binding:"required"`
+	Others    []*multipart.FileHeader `form:"others"`
+	Thumbnail []byte                  `form:"thumbnail"`
+	Internal  string                  `form:"-"`
+}
+
+// UploadPetPhoto uploads a photo of a pet
+// @openapi:form UploadPhoto
+// @openapi:path
+// /pets/{id}/photos:
+//	post:
+//		responses:
+//			"201":
+//				description: "The photo is uploaded"
+func UploadPetPhoto() {}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// CreatePet creates a pet
+// @openapi:path
+// /pets:
+//	post:
+//		responses:
+//			"201":
+//				description: "The pet is created"
+//			"409":
+//				description: "The pet already exists"
+//			"4XX":
+//				description: "The request is invalid"
+func CreatePet(w http.ResponseWriter, r *http.Request) {
+	if r.Body == nil {
+		http.Error(w, "no body", http.StatusBadRequest)
+		return
+	}
+	if r.URL.Query().Get("fail") != "" {
+		writeError(w, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("location", "/pets/1")
+	w.WriteHeader(http.StatusCreated)
+}
+
+// GetPet returns a pet
+// @openapi:path
+// /pets/{id}:
+//	get:
+//		responses:
+//			"200":
+//				description: "The pet"
+//			"404":
+//				description: "The pet doesn't exist"
+//			"default":
+//				description: "An error occurred"
+func GetPet(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("unknown") != "" {
+		writeError(w, 503)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"name": "Rex"})
+}
+
+func writeError(w http.ResponseWriter, code int) {
+	w.Header().Add("Retry-After", "10")
+	w.WriteHeader(code)
+	w.Write([]byte(http.StatusText(code)))
+}
@@ -0,0 +1,32 @@
+package cmd
+
+// Pagination parameters
+type Pagination struct {
+	// Maximum number of items
+	// @openapi:example 20
+	Limit  int    `query:"limit"`
+	Cursor string `query:"cursor"` // Cursor of the next page
+}
+
+// ListPetsParams are the parameters of ListPets
+type ListPetsParams struct {
+	Pagination
+
+	DeviceID  int      `uri:"deviceId"`
+	Tag       []string `form:"tag" binding:"required"`
+	Kind      string   `schema:"kind" validate:"oneof=cat dog"`
+	RequestID string   `header:"X-Request-ID"`
+	Internal  string   `query:"-"`
+	Body      string   `json:"body"`
+}
+
+// ListPets returns the pets of a device
+// @openapi:parameters ListPetsParams
+// @openapi:path
+// /devices/{deviceId}/pets:
+//	get:
+//		description: "Returns the pets of a device"
+//		responses:
+//			"200":
+//				description: "A list of pets."
+func ListPets() {}
@@ -0,0 +1,61 @@
+package cmd
+
+// Node struct
+// @openapi:schema
+type Node struct {
+	Name     string          `json:"name"`
+	Parent   *Node           `json:"parent"`
+	Children []*Node         `json:"children"`
+	Index    map[string]Node `json:"index"`
+}
+
+// Employee struct
+// @openapi:schema
+type Employee struct {
+	Name    string   `json:"name"`
+	Manager *Manager `json:"manager"`
+}
+
+// Manager struct
+// @openapi:schema
+type Manager struct {
+	Name    string     `json:"name"`
+	Reports []Employee `json:"reports"`
+}
+
+// SelfEmbedded struct
+// @openapi:schema
+type SelfEmbedded struct {
+	*SelfEmbedded
+
+	Name string `json:"name"`
+}
+
+// Left struct
+// @openapi:schema
+type Left struct {
+	*Right
+
+	LeftName string `json:"left_name"`
+}
+
+// Right struct
+// @openapi:schema
+type Right struct {
+	*Left
+
+	RightName string `json:"right_name"`
+}
+
+// Tree is a recursive generic type
+type Tree[T any] struct {
+	Value    T         `json:"value"`
+	Children []Tree[T] `json:"children"`
+	Parent   *Tree[T]  `json:"parent"`
+}
+
+// Forest struct
+// @openapi:schema
+type Forest struct {
+	Trees []Tree[Node] `json:"trees"`
+}
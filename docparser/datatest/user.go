@@ -121,8 +121,15 @@ type Pet struct {
 	JSONData            json.RawMessage           `json:"json_data"`
 	CustomString        otherpackage.CustomString `json:"custom_string"`
 	Test                Test                      `json:"test"`
+	SliceOfMapOfFoo     []map[string]*Foo         `json:"sliceOfMapOfFoo"`
+	MapOfSliceOfTime    map[string][]time.Time    `json:"mapOfSliceOfTime"`
+	Checksum            [16]byte                  `json:"checksum"`
 	Anonymous           struct {
-		Field string `json:"field"`
+		Foo
+
+		Field    string `json:"field" validate:"required"`
+		Kind     string `json:"kind" validate:"enum=A B"`
+		Internal string `json:"-"`
 	} `json:"anonymous"`
 }
 
@@ -163,6 +170,26 @@ type Signals []Foo
 // @openapi:schema
 type Test int
 
+// Page is a generic page of results
+type Page[T any] struct {
+	Items []T    `json:"items"`
+	Next  string `json:"next"`
+}
+
+// Pair is a generic couple of values
+type Pair[K any, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+}
+
+// PetPages struct
+// @openapi:schema
+type PetPages struct {
+	Current  Page[Pet]               `json:"current"`
+	Previous *Page[Pet]              `json:"previous"`
+	Best     Pair[string, Page[Dog]] `json:"best"`
+}
+
 // @openapi:info
 //  version: 0.0.1
 //  title: Some cool title
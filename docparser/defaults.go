@@ -0,0 +1,85 @@
+package docparser
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// noDefaultResponses is the key of an operation opting out of the default
+// responses, its value is true or the list of the status codes to skip
+const noDefaultResponses = "x-no-default-responses"
+
+var regexpStatusCode = regexp.MustCompile(`^([1-5]\d\d|[1-5]XX|default)$`)
+
+// SetDefaultResponses sets the responses added to every operation of the
+// paths which doesn't define their status code. A response is given by the
+// $ref of a response component, or by a Go type or the $ref of a schema which
+// is the json content of the response.
+func (spec *openAPI) SetDefaultResponses(responses map[string]string) error {
+	for code, ref := range responses {
+		if !regexpStatusCode.MatchString(code) {
+			return fmt.Errorf("invalid status code %q for a default response", code)
+		}
+		if ref == "" {
+			return fmt.Errorf("default response %s requires a $ref or a Go type", code)
+		}
+	}
+	spec.defaultResponses = responses
+	return nil
+}
+
+// defaultResponse returns the default response of a status code, a new one
+// for every operation since the refs of its schema are rewritten in place
+func defaultResponse(code, ref string) response {
+	if strings.HasPrefix(ref, "#/components/responses/") {
+		return response{Ref: ref}
+	}
+
+	description := "Default response"
+	if c, err := strconv.Atoi(code); err == nil && http.StatusText(c) != "" {
+		description = http.StatusText(c)
+	}
+	return response{
+		Description: description,
+		Content: map[string]content{
+			"application/json": {Schema: &schema{Ref: ref}},
+		},
+	}
+}
+
+// addDefaultResponses adds the default responses to an operation, except the
+// ones it defines or opts out of with x-no-default-responses
+func (spec *openAPI) addDefaultResponses(op *operation) error {
+	skip := map[string]bool{}
+	all := false
+	if v, ok := op.Extensions[noDefaultResponses]; ok {
+		delete(op.Extensions, noDefaultResponses)
+		switch value := v.(type) {
+		case bool:
+			all = value
+		case []interface{}:
+			for _, code := range value {
+				skip[fmt.Sprint(code)] = true
+			}
+		default:
+			return fmt.Errorf("%s must be a boolean or a list of status codes", noDefaultResponses)
+		}
+	}
+	if all || len(spec.defaultResponses) == 0 {
+		return nil
+	}
+
+	for code, ref := range spec.defaultResponses {
+		if _, ok := op.Responses[code]; ok || skip[code] {
+			continue
+		}
+		if op.Responses == nil {
+			op.Responses = make(map[string]response)
+		}
+		op.Responses[code] = defaultResponse(code, ref)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package docparser
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestSetDefaultResponses(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses map[string]string
+		wantErr   bool
+	}{
+		{name: "code", responses: map[string]string{"404": "ErrorResponse"}},
+		{name: "range and default", responses: map[string]string{"5XX": "ErrorResponse", "default": "#/components/responses/Error"}},
+		{name: "invalid code", responses: map[string]string{"600": "ErrorResponse"}, wantErr: true},
+		{name: "no ref", responses: map[string]string{"404": ""}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := NewOpenAPI()
+			err := spec.SetDefaultResponses(tc.responses)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestDefaultResponses(t *testing.T) {
+	src := `package test
+
+// ErrorResponse is returned on errors
+// @openapi:schema
+type ErrorResponse struct {
+	Message string ` + "`json:\"message\"`" + `
+}
+
+// @openapi:path
+// /pets:
+//	get:
+//		responses:
+//			"200":
+//				description: The pets
+//			"404":
+//				description: No pet
+//	post:
+//		x-no-default-responses: ["401"]
+//		responses:
+//			"201":
+//				description: Created
+//	delete:
+//		x-no-default-responses: true
+//		responses:
+//			"204":
+//				description: Deleted
+func Pets() {}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+	assert.NoError(t, err)
+
+	spec := NewOpenAPI()
+	assert.NoError(t, spec.SetDefaultResponses(map[string]string{
+		"401": "#/components/responses/Unauthorized",
+		"404": "ErrorResponse",
+		"500": "ErrorResponse",
+	}))
+	assert.Empty(t, spec.parseSchemas(f))
+	assert.Empty(t, spec.parsePaths(f))
+	spec.resolveTypeRefs()
+
+	ops := spec.Paths["/pets"].Operations
+	b, err := yaml.Marshal(ops["get"].Responses)
+	assert.NoError(t, err)
+	assert.Equal(t, `"200":
+  description: The pets
+"401":
+  $ref: '#/components/responses/Unauthorized'
+"404":
+  description: No pet
+"500":
+  content:
+    application/json:
+      schema:
+        $ref: '#/components/schemas/ErrorResponse'
+  description: Internal Server Error
+`, string(b))
+
+	assert.Len(t, ops["post"].Responses, 3)
+	assert.NotContains(t, ops["post"].Responses, "401")
+	assert.NotContains(t, ops["post"].Extensions, noDefaultResponses)
+
+	assert.Len(t, ops["delete"].Responses, 1)
+	assert.NotContains(t, ops["delete"].Extensions, noDefaultResponses)
+}
+
+func TestDefaultResponsesInvalidOptOut(t *testing.T) {
+	spec := NewOpenAPI()
+	assert.NoError(t, spec.SetDefaultResponses(map[string]string{"404": "ErrorResponse"}))
+
+	op := operation{Extensions: extensions{noDefaultResponses: "yes"}}
+	assert.Error(t, spec.addDefaultResponses(&op))
+}
@@ -0,0 +1,671 @@
+package docparser
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+// docsTemplates are the templates of the documentation, by format
+//
+//go:embed templates
+var docsTemplates embed.FS
+
+// DocsFormats are the formats of the documentation
+var DocsFormats = []string{"html", "markdown"}
+
+var (
+	regexpAnchor     = regexp.MustCompile(`[^a-z0-9]+`)
+	regexpBlankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// defaultTag is the tag of the operations without tags
+const defaultTag = "default"
+
+// docs is the documentation of a document, as rendered by the templates
+type docs struct {
+	Info     info
+	Servers  []server
+	Groups   []docsGroup
+	Webhooks []docsOperation
+	Schemas  []docsSchema
+}
+
+// docsGroup is a group of tags, the tags without a group are in a last group
+type docsGroup struct {
+	Name string
+	Tags []docsTag
+}
+
+type docsTag struct {
+	Name        string
+	Anchor      string
+	Description string
+	Operations  []docsOperation
+}
+
+type docsOperation struct {
+	Verb        string
+	URL         string
+	Anchor      string
+	Summary     string
+	Description string
+	Deprecated  bool
+	Parameters  []docsParameter
+	RequestBody *docsBody
+	Responses   []docsResponse
+	Callbacks   []docsCallback
+}
+
+// docsCallback is a callback of an operation, its operations are by
+// expression then by verb
+type docsCallback struct {
+	Name       string
+	Operations []docsOperation
+}
+
+type docsParameter struct {
+	Name        string
+	In          string
+	Type        docsType
+	Required    bool
+	Description string
+}
+
+type docsBody struct {
+	Description string
+	Required    bool
+	Contents    []docsContent
+}
+
+type docsResponse struct {
+	Code        string
+	Description string
+	Contents    []docsContent
+}
+
+type docsContent struct {
+	MediaType  string
+	Type       docsType
+	Properties []docsProperty
+	Examples   []docsExample
+}
+
+type docsSchema struct {
+	Name        string
+	Anchor      string
+	Description string
+	Type        docsType
+	Properties  []docsProperty
+	Examples    []docsExample
+}
+
+// docsType is the type of a schema, Ref is the schema it links to
+type docsType struct {
+	Text string
+	Ref  string
+}
+
+type docsProperty struct {
+	Name        string
+	Type        docsType
+	Format      string
+	Required    bool
+	Enum        []string
+	Description string
+	Example     string
+}
+
+type docsExample struct {
+	Name  string
+	Value string
+}
+
+// Docs renders the documentation of the document, in html or markdown. The
+// operations are grouped by tag, and the tags by x-tagGroups.
+func (spec *openAPI) Docs(format string) ([]byte, error) {
+	d, err := spec.docs()
+	if err != nil {
+		return nil, err
+	}
+
+	funcs := map[string]interface{}{
+		"join":  strings.Join,
+		"upper": strings.ToUpper,
+		// cell returns a text on one line, for the cells of markdown tables
+		"cell": func(s string) string { return strings.ReplaceAll(strings.Join(strings.Fields(s), " "), "|", `\|`) },
+	}
+	buf := bytes.Buffer{}
+	switch format {
+	case "markdown":
+		t, err := template.New("docs.md.tmpl").Funcs(funcs).ParseFS(docsTemplates, "templates/docs.md.tmpl")
+		if err != nil {
+			return nil, err
+		}
+		if err := t.Execute(&buf, d); err != nil {
+			return nil, err
+		}
+		// the blank lines around the optional blocks are collapsed
+		return regexpBlankLines.ReplaceAll(buf.Bytes(), []byte("\n\n")), nil
+	case "html":
+		t, err := htmltemplate.New("docs.html.tmpl").Funcs(funcs).ParseFS(docsTemplates, "templates/docs.html.tmpl")
+		if err != nil {
+			return nil, err
+		}
+		err = t.Execute(&buf, d)
+		return buf.Bytes(), err
+	}
+	return nil, fmt.Errorf("unknown format %q, %s expected", format, strings.Join(DocsFormats, " or "))
+}
+
+// docs builds the documentation of the document
+func (spec *openAPI) docs() (docs, error) {
+	d := docs{Info: spec.Info, Servers: spec.Servers}
+
+	schemas, err := spec.componentSchemas()
+	if err != nil {
+		return d, err
+	}
+	r := docsRenderer{schemas: schemas, components: spec.Components}
+
+	byTag := make(map[string][]docsOperation)
+	for _, url := range sortedKeys(reflect.ValueOf(spec.Paths)) {
+		p := spec.Paths[url]
+		if p.isRaw {
+			continue
+		}
+		for _, verb := range verbs {
+			op, ok := p.Operations[verb]
+			if !ok {
+				continue
+			}
+			o := r.operation(url, verb, p, op)
+			tags := op.Tags
+			if len(tags) == 0 {
+				tags = []string{defaultTag}
+			}
+			for _, tg := range tags {
+				byTag[tg] = append(byTag[tg], o)
+			}
+		}
+	}
+
+	descriptions := make(map[string]string, len(spec.Tags))
+	for _, tg := range spec.Tags {
+		descriptions[tg.Name] = tg.Description
+	}
+	newTag := func(name string) docsTag {
+		return docsTag{Name: name, Anchor: anchor("tag", name), Description: descriptions[name], Operations: byTag[name]}
+	}
+
+	groups, err := tagGroups(spec.XGroupTags)
+	if err != nil {
+		return d, err
+	}
+	grouped := make(map[string]bool)
+	for _, g := range groups {
+		group := docsGroup{Name: g.Name}
+		for _, name := range g.Tags {
+			grouped[name] = true
+			if len(byTag[name]) > 0 {
+				group.Tags = append(group.Tags, newTag(name))
+			}
+		}
+		d.Groups = append(d.Groups, group)
+	}
+
+	// the tags without a group, in the order of their declaration, then the
+	// undeclared tags by name and the default tag
+	others := docsGroup{}
+	for _, tg := range spec.Tags {
+		if !grouped[tg.Name] && len(byTag[tg.Name]) > 0 {
+			grouped[tg.Name] = true
+			others.Tags = append(others.Tags, newTag(tg.Name))
+		}
+	}
+	names := make([]string, 0, len(byTag))
+	for name := range byTag {
+		if !grouped[name] && name != defaultTag {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if !grouped[defaultTag] && len(byTag[defaultTag]) > 0 {
+		names = append(names, defaultTag)
+	}
+	for _, name := range names {
+		others.Tags = append(others.Tags, newTag(name))
+	}
+	if len(others.Tags) > 0 {
+		others.Name = "Operations"
+		if len(d.Groups) > 0 {
+			others.Name = "Other operations"
+		}
+		d.Groups = append(d.Groups, others)
+	}
+
+	for _, name := range sortedKeys(reflect.ValueOf(spec.Webhooks)) {
+		p := spec.Webhooks[name]
+		if p.isRaw {
+			continue
+		}
+		for _, verb := range verbs {
+			op, ok := p.Operations[verb]
+			if !ok {
+				continue
+			}
+			o := r.operation(name, verb, p, op)
+			o.Anchor = anchor("webhook", verb+" "+name)
+			d.Webhooks = append(d.Webhooks, o)
+		}
+	}
+
+	schemaNames := make([]string, 0, len(schemas))
+	for name := range schemas {
+		schemaNames = append(schemaNames, name)
+	}
+	sort.Strings(schemaNames)
+	for _, name := range schemaNames {
+		s := schemas[name]
+		d.Schemas = append(d.Schemas, docsSchema{
+			Name:        name,
+			Anchor:      anchor("schema", name),
+			Description: s.Description,
+			Type:        r.typeOf(s),
+			Properties:  r.properties(s),
+			Examples:    examples(nil, nil, s.Example),
+		})
+	}
+	return d, nil
+}
+
+// docsRenderer renders the operations and the schemas, the references to the
+// components are followed
+type docsRenderer struct {
+	schemas    map[string]*schema
+	components Components
+}
+
+func (r docsRenderer) operation(url, verb string, p path, op operation) docsOperation {
+	o := docsOperation{
+		Verb:        verb,
+		URL:         url,
+		Anchor:      anchor("operation", verb+" "+url),
+		Summary:     op.Summary,
+		Description: op.Description,
+		Deprecated:  op.Deprecated,
+	}
+
+	for _, prm := range append(append([]parameter{}, p.Parameters...), op.Parameters...) {
+		if prm.Ref != "" {
+			resolved, ok := r.components.Parameters[refName(prm.Ref)]
+			if !ok {
+				o.Parameters = append(o.Parameters, docsParameter{Name: refName(prm.Ref)})
+				continue
+			}
+			prm = resolved
+		}
+		o.Parameters = append(o.Parameters, docsParameter{
+			Name:        prm.Name,
+			In:          prm.In,
+			Type:        r.typeOf(prm.Schema),
+			Required:    prm.Required,
+			Description: prm.Description,
+		})
+	}
+
+	body := op.RequestBody
+	if resolved, ok := r.components.RequestBodies[refName(body.Ref)]; ok && body.Ref != "" {
+		body = resolved
+	}
+	if body.Ref != "" || len(body.Content) > 0 {
+		o.RequestBody = &docsBody{
+			Description: body.Description,
+			Required:    body.Required,
+			Contents:    r.contents(body.Content),
+		}
+		if body.Ref != "" {
+			o.RequestBody.Description = refName(body.Ref)
+		}
+	}
+
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		resp := op.Responses[code]
+		if resolved, ok := r.components.Responses[refName(resp.Ref)]; ok && resp.Ref != "" {
+			resp = resolved
+		}
+		description := resp.Description
+		if resp.Ref != "" {
+			description = refName(resp.Ref)
+		}
+		o.Responses = append(o.Responses, docsResponse{
+			Code:        code,
+			Description: description,
+			Contents:    r.contents(resp.Content),
+		})
+	}
+
+	for _, name := range sortedKeys(reflect.ValueOf(op.Callbacks)) {
+		cb := docsCallback{Name: name}
+		items := r.callback(op.Callbacks[name])
+		for _, expr := range sortedKeys(reflect.ValueOf(items)) {
+			item := items[expr]
+			if item.isRaw {
+				continue
+			}
+			for _, verb := range verbs {
+				cop, ok := item.Operations[verb]
+				if !ok {
+					continue
+				}
+				c := r.operation(expr, verb, item, cop)
+				c.Anchor = o.Anchor + "-" + anchor("callback", name+" "+verb+" "+expr)
+				cb.Operations = append(cb.Operations, c)
+			}
+		}
+		o.Callbacks = append(o.Callbacks, cb)
+	}
+	return o
+}
+
+// callback returns the path items of a callback, the callback is read from
+// the components when it's a reference
+func (r docsRenderer) callback(cb paths) paths {
+	ref, ok := cb["$ref"]
+	if !ok {
+		return cb
+	}
+	if s, ok := ref.raw.(string); ok {
+		if resolved, ok := r.components.Callbacks[refName(s)]; ok {
+			return resolved
+		}
+	}
+	return cb
+}
+
+func (r docsRenderer) contents(c map[string]content) []docsContent {
+	types := make([]string, 0, len(c))
+	for t := range c {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	contents := []docsContent{}
+	for _, t := range types {
+		media := c[t]
+		dc := docsContent{MediaType: t}
+		if media.Schema != nil {
+			dc.Type = r.typeOf(media.Schema)
+			dc.Properties = r.properties(media.Schema)
+		}
+		var schemaExample interface{}
+		if s := r.resolve(media.Schema); s != nil {
+			schemaExample = s.Example
+		}
+		dc.Examples = examples(media.Example, media.Examples, schemaExample)
+		contents = append(contents, dc)
+	}
+	return contents
+}
+
+func (r docsRenderer) resolve(s *schema) *schema {
+	return resolveSchema(r.schemas, s)
+}
+
+// typeOf returns the type of a schema, i.e. array of Pet
+func (r docsRenderer) typeOf(s *schema) docsType {
+	if s == nil {
+		return docsType{}
+	}
+	if s.Ref != "" {
+		name := refName(s.Ref)
+		return docsType{Text: name, Ref: anchor("schema", name)}
+	}
+	for _, c := range []struct {
+		kind    string
+		schemas []*schema
+	}{{"allOf", s.AllOf}, {"oneOf", s.OneOf}, {"anyOf", s.AnyOf}} {
+		if len(c.schemas) == 0 {
+			continue
+		}
+		parts := make([]string, 0, len(c.schemas))
+		for _, part := range c.schemas {
+			t := r.typeOf(part)
+			if t.Text == "" {
+				t.Text = "object"
+			}
+			parts = append(parts, t.Text)
+		}
+		return docsType{Text: c.kind + "(" + strings.Join(parts, ", ") + ")"}
+	}
+	if s.Type == "array" {
+		t := r.typeOf(s.Items)
+		t.Text = "array of " + t.Text
+		return t
+	}
+	if s.Type == "object" && s.AdditionalProperties != nil && s.boolean == nil {
+		t := r.typeOf(s.AdditionalProperties)
+		t.Text = "map of " + t.Text
+		return t
+	}
+	if s.Type == "" {
+		return docsType{Text: "any"}
+	}
+	return docsType{Text: s.Type}
+}
+
+// properties returns the properties of an object schema, the properties of
+// the schemas composed with allOf included
+func (r docsRenderer) properties(s *schema) []docsProperty {
+	return r.collectProperties(s, make(map[string]bool))
+}
+
+func (r docsRenderer) collectProperties(s *schema, visited map[string]bool) []docsProperty {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		name := refName(s.Ref)
+		if visited[name] {
+			return nil
+		}
+		visited[name] = true
+		return r.collectProperties(r.schemas[name], visited)
+	}
+	if s.Type == "array" && s.Items != nil && s.Items.Ref == "" {
+		return r.collectProperties(s.Items, visited)
+	}
+
+	properties := []docsProperty{}
+	for _, part := range s.AllOf {
+		properties = append(properties, r.collectProperties(part, visited)...)
+	}
+
+	required := make(map[string]bool, len(s.Required))
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := s.Properties[name]
+		if p == nil {
+			continue
+		}
+		dp := docsProperty{
+			Name:        name,
+			Type:        r.typeOf(p),
+			Format:      p.Format,
+			Required:    required[name],
+			Description: p.Description,
+		}
+		for _, v := range p.Enum {
+			dp.Enum = append(dp.Enum, fmt.Sprint(v))
+		}
+		if p.Example != nil {
+			dp.Example = compactJSON(p.Example)
+		}
+		properties = append(properties, dp)
+	}
+	return properties
+}
+
+// examples returns the examples of a media type, or the example of its
+// schema when it has none
+func examples(value interface{}, named map[string]example, schemaExample interface{}) []docsExample {
+	list := []docsExample{}
+	if value != nil {
+		list = append(list, docsExample{Value: indentedJSON(value)})
+	}
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		e := named[name]
+		if e.Value == nil {
+			continue
+		}
+		title := e.Summary
+		if title == "" {
+			title = name
+		}
+		list = append(list, docsExample{Name: title, Value: indentedJSON(e.Value)})
+	}
+	if len(list) == 0 && schemaExample != nil {
+		list = append(list, docsExample{Value: indentedJSON(schemaExample)})
+	}
+	return list
+}
+
+// componentSchemas returns the schemas of the components by name
+func (spec *openAPI) componentSchemas() (map[string]*schema, error) {
+	schemas := make(map[string]*schema, len(spec.Components.Schemas))
+	for name, s := range spec.Components.Schemas {
+		sch, err := toSchema(s)
+		if err != nil {
+			return nil, fmt.Errorf("schema %s: %w", name, err)
+		}
+		schemas[name] = sch
+	}
+	return schemas, nil
+}
+
+// resolveSchema returns the component schema of a reference
+func resolveSchema(schemas map[string]*schema, s *schema) *schema {
+	for i := 0; s != nil && s.Ref != "" && i < 10; i++ {
+		s = schemas[refName(s.Ref)]
+	}
+	return s
+}
+
+// toSchema returns a component schema as a schema, the schemas of a document
+// read from a file are maps
+func toSchema(v interface{}) (*schema, error) {
+	switch s := v.(type) {
+	case *schema:
+		return s, nil
+	case *composedSchema:
+		return &schema{AllOf: s.AllOf, Example: s.Example, Extensions: s.Extensions}, nil
+	}
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	s := &schema{}
+	return s, yaml.Unmarshal(b, s)
+}
+
+// tagGroups returns the groups of tags of x-tagGroups, they are maps in a
+// document read from a file
+func tagGroups(groups []interface{}) ([]tagGroup, error) {
+	list := make([]tagGroup, 0, len(groups))
+	for _, g := range groups {
+		if tg, ok := g.(tagGroup); ok {
+			list = append(list, tg)
+			continue
+		}
+		b, err := yaml.Marshal(g)
+		if err != nil {
+			return nil, err
+		}
+		tg := tagGroup{}
+		if err := yaml.Unmarshal(b, &tg); err != nil {
+			return nil, fmt.Errorf("x-tagGroups: %w", err)
+		}
+		list = append(list, tg)
+	}
+	return list, nil
+}
+
+// refName returns the name of the component of a reference
+func refName(ref string) string {
+	if parts := strings.SplitN(ref, "/", 4); len(parts) == 4 && parts[0] == "#" {
+		return parts[3]
+	}
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+// anchor returns the id of a section of the documentation
+func anchor(kind, name string) string {
+	return kind + "-" + strings.Trim(regexpAnchor.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+func indentedJSON(v interface{}) string {
+	b, err := json.MarshalIndent(jsonCompatible(v), "", "  ")
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func compactJSON(v interface{}) string {
+	b, err := json.Marshal(jsonCompatible(v))
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// jsonCompatible turns the maps decoded from yaml into maps with string keys
+func jsonCompatible(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			m[fmt.Sprint(k)] = jsonCompatible(value)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			m[k] = jsonCompatible(value)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, value := range v {
+			l[i] = jsonCompatible(value)
+		}
+		return l
+	}
+	return v
+}
@@ -0,0 +1,220 @@
+package docparser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const docsDocument = `openapi: 3.0.0
+info:
+  title: Pets
+  version: 1.0.0
+tags:
+  - name: pets
+    description: The pets
+  - name: owners
+x-tagGroups:
+  - name: Animals
+    tags:
+      - pets
+paths:
+  /pets:
+    get:
+      tags:
+        - pets
+      summary: List the pets
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+      responses:
+        "200":
+          description: The pets
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/Pet'
+              example:
+                - name: Rex
+  /owners:
+    post:
+      tags:
+        - owners
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Owner'
+      responses:
+        "201":
+          description: The owner
+      callbacks:
+        adopted:
+          '{$request.body#/callback}':
+            post:
+              summary: A pet is adopted
+              responses:
+                "200":
+                  description: Acknowledged
+        moved:
+          $ref: '#/components/callbacks/Moved'
+  /health:
+    get:
+      responses:
+        "204":
+          description: Healthy
+webhooks:
+  newPet:
+    post:
+      summary: A pet is added
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses:
+        "200":
+          description: Acknowledged
+components:
+  callbacks:
+    Moved:
+      '{$request.body#/moved}':
+        put:
+          summary: An owner moves
+          responses:
+            "204":
+              description: Acknowledged
+  schemas:
+    Animal:
+      type: object
+      required:
+        - name
+      properties:
+        name:
+          type: string
+          description: The name
+          example: Rex
+    Pet:
+      allOf:
+        - $ref: '#/components/schemas/Animal'
+        - type: object
+          properties:
+            kind:
+              type: string
+              enum:
+                - cat
+                - dog
+            born:
+              type: string
+              format: date-time
+    Owner:
+      type: object
+      properties:
+        pets:
+          type: array
+          items:
+            $ref: '#/components/schemas/Pet'
+`
+
+func docsSpec(t *testing.T) *openAPI {
+	spec := NewOpenAPI()
+	assert.NoError(t, yaml.Unmarshal([]byte(docsDocument), &spec))
+	return &spec
+}
+
+func TestDocsGroups(t *testing.T) {
+	d, err := docsSpec(t).docs()
+	assert.NoError(t, err)
+
+	groups := map[string][]string{}
+	names := []string{}
+	for _, g := range d.Groups {
+		names = append(names, g.Name)
+		for _, tg := range g.Tags {
+			groups[g.Name] = append(groups[g.Name], tg.Name)
+		}
+	}
+	assert.Equal(t, []string{"Animals", "Other operations"}, names)
+	assert.Equal(t, []string{"pets"}, groups["Animals"])
+	assert.Equal(t, []string{"owners", "default"}, groups["Other operations"])
+
+	for _, s := range d.Schemas {
+		if s.Name != "Pet" {
+			continue
+		}
+		// the properties of the schemas composed with allOf are included
+		assert.Equal(t, []docsProperty{
+			{Name: "name", Type: docsType{Text: "string"}, Required: true, Description: "The name", Example: `"Rex"`},
+			{Name: "born", Type: docsType{Text: "string"}, Format: "date-time"},
+			{Name: "kind", Type: docsType{Text: "string"}, Enum: []string{"cat", "dog"}},
+		}, s.Properties)
+	}
+}
+
+func TestDocs(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected []string
+	}{
+		{
+			format: "markdown",
+			expected: []string{
+				"# Pets 1.0.0",
+				"## Animals",
+				"## Other operations",
+				"#### `GET /pets`",
+				"| `limit` | query | integer |  |  |",
+				"`application/json`: [array of Pet](#schema-pet)",
+				"| `name` | string |  | yes |  | The name | `\"Rex\"` |",
+				"| `kind` | string |  |  | cat, dog |  |  |",
+				"| `born` | string | date-time |  |  |  |  |",
+				"##### Request body (required)",
+				"```json\n[\n  {\n    \"name\": \"Rex\"\n  }\n]\n```",
+				"- [Webhooks](#webhooks)",
+				"## Webhooks",
+				"#### `POST newPet`",
+				"##### Callbacks",
+				"**adopted** `POST {$request.body#/callback}`",
+				"**A pet is adopted**",
+			},
+		},
+		{
+			format: "html",
+			expected: []string{
+				"<!DOCTYPE html>",
+				"<style>",
+				"Animals",
+				"Other operations",
+				`href="#schema-pet"`,
+				"date-time",
+				"<code>cat</code>, <code>dog</code>",
+				"&#34;name&#34;: &#34;Rex&#34;",
+				`<h2 id="webhooks">Webhooks</h2>`,
+				`<a href="#webhook-post-newpet">`,
+				"<h5>Callbacks</h5>",
+				`<span class="path">{$request.body#/moved}</span>`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			d, err := docsSpec(t).Docs(tt.format)
+			assert.NoError(t, err)
+			for _, e := range tt.expected {
+				assert.Contains(t, string(d), e)
+			}
+			assert.False(t, strings.Contains(string(d), "\n\n\n"))
+		})
+	}
+
+	_, err := docsSpec(t).Docs("pdf")
+	assert.Error(t, err)
+}
@@ -0,0 +1,202 @@
+package docparser
+
+import (
+	"errors"
+	"go/ast"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// @openapi:extension x-internal true
+var regexpExtension = regexp.MustCompile(`@openapi:extension (x-[\w.-]+) ([^\n]+)`)
+
+// extensions are the specification extensions of an object, inlined with its
+// fields. Only the keys starting with x- are kept, see dropUnknownFields.
+type extensions map[string]interface{}
+
+var extensionsType = reflect.TypeOf(extensions{})
+
+// parseExtensions returns the extensions given with @openapi:extension in a
+// comment, the values are yaml
+func parseExtensions(comment string) (extensions, error) {
+	var ext extensions
+	for _, m := range regexpExtension.FindAllStringSubmatch(comment, -1) {
+		var value interface{}
+		if err := yaml.Unmarshal([]byte(m[2]), &value); err != nil {
+			return nil, err
+		}
+		if ext == nil {
+			ext = make(extensions)
+		}
+		ext[m[1]] = value
+	}
+	return ext, nil
+}
+
+// setExtensions adds extensions to a schema or a composed schema
+func setExtensions(entity interface{}, ext extensions) {
+	if len(ext) == 0 {
+		return
+	}
+
+	var target *extensions
+	switch e := entity.(type) {
+	case *schema:
+		target = &e.Extensions
+	case *composedSchema:
+		target = &e.Extensions
+	default:
+		return
+	}
+
+	if *target == nil {
+		*target = make(extensions)
+	}
+	for k, v := range ext {
+		(*target)[k] = v
+	}
+}
+
+// dropUnknownFields removes from the extensions of v, and of every object it
+// contains, the keys which aren't specification extensions. It returns the
+// removed keys.
+func dropUnknownFields(v reflect.Value) (keys []string) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			keys = append(keys, dropUnknownFields(v.Elem())...)
+		}
+
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" {
+				continue
+			}
+			fld := v.Field(i)
+			if fld.Type() != extensionsType {
+				keys = append(keys, dropUnknownFields(fld)...)
+				continue
+			}
+			for _, k := range fld.MapKeys() {
+				if !strings.HasPrefix(k.String(), "x-") {
+					keys = append(keys, k.String())
+					fld.SetMapIndex(k, reflect.Value{})
+				}
+			}
+		}
+
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			keys = append(keys, dropUnknownFields(v.MapIndex(k))...)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			keys = append(keys, dropUnknownFields(v.Index(i))...)
+		}
+	}
+	return keys
+}
+
+// dropUnknownFields removes the fields of the document which aren't part of
+// the specification nor extensions
+func (spec *openAPI) dropUnknownFields() {
+	keys := dropUnknownFields(reflect.ValueOf(spec))
+	sort.Strings(keys)
+	for _, k := range keys {
+		logrus.
+			WithField("field", k).
+			Warn("Unknown field, only x- extensions are kept")
+	}
+}
+
+// UnmarshalYAML drops the unknown fields of the document, see
+// dropUnknownFields
+func (spec *openAPI) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain openAPI
+	if err := unmarshal((*plain)(spec)); err != nil {
+		return err
+	}
+	spec.dropUnknownFields()
+	return nil
+}
+
+// parseRootExtensions parses the @openapi:extension annotations of the
+// comments which don't document a declaration or a field, they are the
+// extensions of the document
+func (spec *openAPI) parseRootExtensions(f *ast.File) (errs []error) {
+	docs := make(map[*ast.CommentGroup]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch d := n.(type) {
+		case *ast.GenDecl:
+			docs[d.Doc] = true
+		case *ast.FuncDecl:
+			docs[d.Doc] = true
+		case *ast.TypeSpec:
+			docs[d.Doc] = true
+		case *ast.ValueSpec:
+			docs[d.Doc] = true
+		case *ast.Field:
+			docs[d.Doc] = true
+		}
+		return true
+	})
+
+	for _, c := range f.Comments {
+		if docs[c] {
+			continue
+		}
+		t := c.Text()
+		ext, err := parseExtensions(t)
+		if err != nil {
+			logrus.
+				WithError(err).
+				WithField("content", t).
+				Error("Unable to unmarshal extension")
+			errs = append(errs, &BuildError{
+				Err:     err,
+				Content: t,
+				Message: "unable to unmarshal extension",
+			})
+			continue
+		}
+		for k, v := range ext {
+			if err := spec.addExtension(k, v); err != nil {
+				logrus.
+					WithError(err).
+					WithField("extension", k).
+					Error("Extension already exists and is different")
+				errs = append(errs, &BuildError{
+					Err:     err,
+					Content: k,
+					Message: "extension already exists and is different",
+				})
+				continue
+			}
+			logrus.WithField("name", k).Info("Parsing extension")
+		}
+	}
+	return
+}
+
+func (spec *openAPI) addExtension(name string, value interface{}) error {
+	if name == "x-tagGroups" {
+		return errors.New("x-tagGroups is declared with @openapi:tagGroup")
+	}
+	if current, ok := spec.Extensions[name]; ok {
+		if !reflect.DeepEqual(current, value) {
+			return errors.New("extension already exists and is different")
+		}
+		return nil
+	}
+	if spec.Extensions == nil {
+		spec.Extensions = make(extensions)
+	}
+	spec.Extensions[name] = value
+	return nil
+}
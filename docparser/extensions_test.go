@@ -0,0 +1,130 @@
+package docparser
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const extensionsSource = `package test
+
+// @openapi:extension x-api-id 4f2d
+// @openapi:extension x-audience [public, partner]
+
+// Pet is a pet
+// @openapi:schema
+// @openapi:extension x-internal true
+type Pet struct {
+	// @openapi:extension x-codegen-name petName
+	Name string ` + "`json:\"name\"`" + `
+}
+
+type Filters struct {
+	// @openapi:extension x-rate-limit 10
+	Limit int ` + "`query:\"limit\"`" + `
+}
+
+// @openapi:path
+// /pets:
+//	get:
+//		x-amazon-apigateway-integration:
+//			type: http_proxy
+//			httpMethod: GET
+//		unknown: dropped
+//		parameters:
+//			- in: query
+//			  name: name
+//			  x-example-source: doc
+//			  schema:
+//					type: string
+//					x-nullable-legacy: true
+//		responses:
+//			"200":
+//				description: OK
+//				x-cache: 60
+func Pets() {}
+`
+
+func TestParseExtensions(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "", extensionsSource, parser.ParseComments)
+	assert.NoError(t, err)
+
+	spec := NewOpenAPI()
+	assert.Empty(t, spec.parseSchemas(f))
+	assert.Empty(t, spec.parseRootExtensions(f))
+	assert.Empty(t, spec.parsePaths(f))
+	spec.dropUnknownFields()
+
+	assert.Equal(t, extensions{"x-api-id": "4f2d", "x-audience": []interface{}{"public", "partner"}}, spec.Extensions)
+
+	pet := spec.registeredSchemas["Pet"].(*schema)
+	assert.Equal(t, extensions{"x-internal": true}, pet.Extensions)
+	assert.Equal(t, extensions{"x-codegen-name": "petName"}, pet.Properties["name"].Extensions)
+
+	params, err := spec.structParameters("Filters", map[string]bool{})
+	assert.NoError(t, err)
+	assert.Equal(t, extensions{"x-rate-limit": 10}, params[0].Extensions)
+
+	b, err := yaml.Marshal(spec.Paths["/pets"].Operations["get"])
+	assert.NoError(t, err)
+	assert.Equal(t, `responses:
+  "200":
+    description: OK
+    x-cache: 60
+parameters:
+- in: query
+  name: name
+  schema:
+    type: string
+    x-nullable-legacy: true
+  x-example-source: doc
+x-amazon-apigateway-integration:
+  httpMethod: GET
+  type: http_proxy
+`, string(b))
+}
+
+func TestParseRootExtensionsConflict(t *testing.T) {
+	src := `package test
+
+// @openapi:extension x-api-id 4f2d
+
+// @openapi:extension x-api-id 5e3c
+
+// @openapi:extension x-tagGroups []
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+	assert.NoError(t, err)
+
+	spec := NewOpenAPI()
+	assert.Len(t, spec.parseRootExtensions(f), 2)
+	assert.Equal(t, extensions{"x-api-id": "4f2d"}, spec.Extensions)
+}
+
+func TestUnmarshalOpenAPIExtensions(t *testing.T) {
+	src := `openapi: 3.0.0
+x-api-id: 4f2d
+paths:
+  /pets:
+    get:
+      x-internal: true
+      unknown: dropped
+      responses: {}
+components:
+  schemas: {}
+  parameters:
+    Limit:
+      in: query
+      name: limit
+      x-rate-limit: 10
+`
+	spec := NewOpenAPI()
+	assert.NoError(t, yaml.Unmarshal([]byte(src), &spec))
+
+	assert.Equal(t, extensions{"x-api-id": "4f2d"}, spec.Extensions)
+	assert.Equal(t, extensions{"x-internal": true}, spec.Paths["/pets"].Operations["get"].Extensions)
+	assert.Equal(t, extensions{"x-rate-limit": 10}, spec.Components.Parameters["Limit"].Extensions)
+}
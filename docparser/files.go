@@ -0,0 +1,153 @@
+package docparser
+
+import (
+	"fmt"
+	"go/ast"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// regexpGenerated matches the comment of the generated files, see
+// https://golang.org/s/generatedcode
+var regexpGenerated = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// SetInclude sets the doublestar patterns of the files parsed, relative to the
+// parsed folder, i.e. api/**/*.go. Every file is parsed when there is none.
+func (spec *openAPI) SetInclude(patterns []string) error {
+	if err := validatePatterns(patterns); err != nil {
+		return err
+	}
+	spec.include = patterns
+	return nil
+}
+
+// SetExclude sets the doublestar patterns of the files and folders which
+// aren't parsed, a pattern matches the path relative to the parsed folder or
+// the base name, i.e. legacy or **/*_mock.go
+func (spec *openAPI) SetExclude(patterns []string) error {
+	if err := validatePatterns(patterns); err != nil {
+		return err
+	}
+	spec.exclude = patterns
+	return nil
+}
+
+// SetTags sets the build tags satisfied by the parsed files, with the ones of
+// the current platform. The files whose build constraints aren't satisfied
+// are skipped.
+func (spec *openAPI) SetTags(tags []string) {
+	spec.build.BuildTags = tags
+}
+
+func validatePatterns(patterns []string) error {
+	for _, p := range patterns {
+		if !doublestar.ValidatePattern(p) {
+			return fmt.Errorf("invalid pattern %q", p)
+		}
+	}
+	return nil
+}
+
+// relativePath returns the path relative to the root of the parsed folder,
+// with slashes
+func relativePath(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// excluded tells if the path, in the parsed folder root, is excluded
+func (spec *openAPI) excluded(root, path string) bool {
+	rel := relativePath(root, path)
+	for _, p := range spec.exclude {
+		if ok, _ := doublestar.Match(p, rel); ok {
+			return true
+		}
+		if ok, _ := doublestar.Match(p, filepath.Base(path)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// skippedDir tells if a folder is skipped like the go tool does, the test
+// data and the folders starting with a dot or an underscore
+func skippedDir(name string) bool {
+	return name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+// selected tells if a go file of the parsed folder root is parsed: it's
+// included, not excluded, and its build constraints are satisfied
+func (spec *openAPI) selected(root, path string) bool {
+	if spec.excluded(root, path) {
+		return false
+	}
+
+	if len(spec.include) > 0 {
+		included := false
+		for _, p := range spec.include {
+			if ok, _ := doublestar.Match(p, relativePath(root, path)); ok {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+
+	dir, name := filepath.Split(path)
+	ok, err := spec.build.MatchFile(dir, name)
+	if err != nil {
+		logrus.
+			WithError(err).
+			WithField("file", path).
+			Warn("Unable to read the build constraints")
+		return false
+	}
+	if !ok {
+		logrus.
+			WithField("file", path).
+			Debug("Build constraints not satisfied")
+	}
+	return ok
+}
+
+// isGenerated tells if a parsed file is generated
+func isGenerated(f *ast.File) bool {
+	for _, cg := range f.Comments {
+		if cg.Pos() > f.Package {
+			break
+		}
+		for _, c := range cg.List {
+			if regexpGenerated.MatchString(c.Text) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// vendoredPackage returns the import path of a file of a vendor folder
+func vendoredPackage(path string) (string, bool) {
+	segments := strings.Split(filepath.ToSlash(filepath.Dir(path)), "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if segments[i] == "vendor" {
+			return strings.Join(segments[i+1:], "/"), true
+		}
+	}
+	return "", false
+}
+
+// importPathHasPrefix tells if the package pkg is prefix or one of its
+// subpackages
+func importPathHasPrefix(pkg, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return pkg == prefix || strings.HasPrefix(pkg, prefix+"/")
+}
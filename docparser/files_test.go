@@ -0,0 +1,116 @@
+package docparser
+
+import (
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExcluded(t *testing.T) {
+	spec := NewOpenAPI()
+	assert.NoError(t, spec.SetExclude([]string{"legacy", "**/*_gen.go", "internal/**"}))
+
+	tests := []struct {
+		path     string
+		excluded bool
+	}{
+		{path: "api/legacy", excluded: true},
+		{path: "api/models_gen.go", excluded: true},
+		{path: "api/v1/models/pet_gen.go", excluded: true},
+		{path: "api/internal/db/pets.go", excluded: true},
+		{path: "api/handlers/pets.go", excluded: false},
+		{path: "api", excluded: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			assert.Equal(t, tc.excluded, spec.excluded("api", tc.path))
+		})
+	}
+
+	assert.Error(t, spec.SetExclude([]string{"[invalid"}))
+}
+
+func TestSelected(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"handlers.go":   "package api\n",
+		"enterprise.go": "//go:build enterprise\n\npackage api\n",
+		"legacy.go":     "// +build !enterprise\n\npackage api\n",
+		"models.go":     "package api\n",
+	}
+	for name, src := range files {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644))
+	}
+
+	tests := []struct {
+		name     string
+		include  []string
+		tags     []string
+		selected []string
+	}{
+		{
+			name:     "default",
+			selected: []string{"handlers.go", "legacy.go", "models.go"},
+		},
+		{
+			name:     "tags",
+			tags:     []string{"enterprise"},
+			selected: []string{"enterprise.go", "handlers.go", "models.go"},
+		},
+		{
+			name:     "include",
+			include:  []string{"handlers.go", "**/e*.go"},
+			tags:     []string{"enterprise"},
+			selected: []string{"enterprise.go", "handlers.go"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := NewOpenAPI()
+			assert.NoError(t, spec.SetInclude(tc.include))
+			spec.SetTags(tc.tags)
+
+			selected := []string{}
+			for _, name := range []string{"enterprise.go", "handlers.go", "legacy.go", "models.go"} {
+				if spec.selected(dir, filepath.Join(dir, name)) {
+					selected = append(selected, name)
+				}
+			}
+			assert.Equal(t, tc.selected, selected)
+		})
+	}
+}
+
+func TestIsGenerated(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       string
+		generated bool
+	}{
+		{name: "generated", src: "// Code generated by mockgen. DO NOT EDIT.\n\npackage api\n", generated: true},
+		{name: "after the license", src: "// Copyright 2020\n\n// Code generated by stringer; DO NOT EDIT.\n\npackage api\n", generated: true},
+		{name: "not generated", src: "// Package api\npackage api\n"},
+		{name: "after the package clause", src: "package api\n\n// Code generated by hand. DO NOT EDIT.\nvar A int\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := parser.ParseFile(token.NewFileSet(), "", tc.src, parser.ParseComments)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.generated, isGenerated(f))
+		})
+	}
+}
+
+func TestSkippedDir(t *testing.T) {
+	assert.True(t, skippedDir("testdata"))
+	assert.True(t, skippedDir(".git"))
+	assert.True(t, skippedDir("_examples"))
+	assert.False(t, skippedDir("handlers"))
+}
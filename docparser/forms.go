@@ -0,0 +1,257 @@
+package docparser
+
+import (
+	"fmt"
+	"go/ast"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	multipartForm  = "multipart/form-data"
+	urlEncodedForm = "application/x-www-form-urlencoded"
+)
+
+var (
+	// @openapi:form UploadPhoto multipart/form-data
+	regexpForm = regexp.MustCompile(`@openapi:form ([\w.]+)(?: ([\w./+-]+))?`)
+	// @openapi:contentType image/png, image/jpeg
+	regexpContentType = regexp.MustCompile(`@openapi:contentType ([^\n]+)`)
+)
+
+// formBody is a struct expanded into a form request body, written as the name
+// of the struct followed by the optional content type of the form
+type formBody struct {
+	Struct      string
+	ContentType string
+}
+
+func parseFormBody(s string) (*formBody, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, fmt.Errorf("invalid form %q", s)
+	}
+
+	form := &formBody{Struct: fields[0], ContentType: multipartForm}
+	if len(fields) == 2 {
+		form.ContentType = fields[1]
+	}
+	if form.ContentType != multipartForm && form.ContentType != urlEncodedForm {
+		return nil, fmt.Errorf("form content type can't be %s", form.ContentType)
+	}
+	return form, nil
+}
+
+func (b *formBody) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	form, err := parseFormBody(s)
+	if err != nil {
+		return err
+	}
+	*b = *form
+	return nil
+}
+
+func (b formBody) MarshalYAML() (interface{}, error) {
+	return b.Struct + " " + b.ContentType, nil
+}
+
+// formAnnotation returns the form given with @openapi:form in a comment
+func formAnnotation(comment string) (*formBody, error) {
+	m := regexpForm.FindStringSubmatch(comment)
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return parseFormBody(strings.TrimSpace(m[1] + " " + m[2]))
+}
+
+// expandRequestBodies adds the forms given with @openapi:form or
+// requestBodyFrom to the request bodies of the operations, the ones of the
+// webhooks and of the callbacks included. A content written in the comment
+// for the same content type is kept.
+func (spec *openAPI) expandRequestBodies() (errs []error) {
+	spec.walkOperations(func(location, verb string, op *operation) {
+		form := op.RequestBodyFrom
+		op.RequestBodyFrom = nil
+		if form == nil || op.RequestBody.Ref != "" {
+			return
+		}
+		if _, ok := op.RequestBody.Content[form.ContentType]; ok {
+			return
+		}
+
+		c, err := spec.formContent(form.Struct, form.ContentType)
+		if err != nil {
+			logrus.
+				WithError(err).
+				WithField("location", location).
+				WithField("verb", verb).
+				Error("Can't expand form")
+			errs = append(errs, BuildError{
+				Err:     err,
+				Content: fmt.Sprintf("%s, verb: %s", location, verb),
+				Message: "can't expand form",
+			})
+			return
+		}
+
+		if op.RequestBody.Content == nil {
+			op.RequestBody.Content = make(map[string]content)
+		}
+		op.RequestBody.Content[form.ContentType] = c
+	})
+	return errs
+}
+
+// formContent builds the content of a form from the fields of a struct, the
+// files of a multipart form get an encoding with their content type
+func (spec *openAPI) formContent(name, contentType string) (content, error) {
+	fields, err := spec.structFields(name, map[string]bool{})
+	if err != nil {
+		return content{}, err
+	}
+
+	s := newEntity()
+	s.Type = "object"
+	encodings := make(map[string]encoding)
+	for _, sf := range fields {
+		part, ok, err := parseFormField(sf.file, sf.field)
+		if err != nil {
+			return content{}, fmt.Errorf("field %s of %s: %w", sf.field.Names[0].Name, sf.owner, err)
+		}
+		if !ok {
+			continue
+		}
+		if part.file && contentType != multipartForm {
+			return content{}, fmt.Errorf("field %s of %s: files can only be sent with %s", sf.field.Names[0].Name, sf.owner, multipartForm)
+		}
+
+		s.Properties[part.name] = part.schema
+		if part.required {
+			s.Required = append(s.Required, part.name)
+		}
+		if part.contentType != "" && contentType == multipartForm {
+			encodings[part.name] = encoding{ContentType: part.contentType}
+		}
+	}
+
+	c := content{Schema: &s}
+	if len(encodings) > 0 {
+		c.Encoding = encodings
+	}
+	return c, nil
+}
+
+// formPart is a field of a form
+type formPart struct {
+	name        string
+	schema      *schema
+	required    bool
+	file        bool
+	contentType string
+}
+
+// parseFormField builds the part of a form from a struct field, ok is false
+// when the field is ignored
+func parseFormField(f *ast.File, fld *ast.Field) (part formPart, ok bool, err error) {
+	part.name = fld.Names[0].Name
+
+	var st reflect.StructTag
+	if fld.Tag != nil {
+		tv, err := strconv.Unquote(fld.Tag.Value)
+		if err != nil {
+			return part, false, err
+		}
+		st = reflect.StructTag(tv)
+	}
+
+	for _, tag := range []string{"form", "schema"} {
+		value, found := st.Lookup(tag)
+		if !found {
+			continue
+		}
+		options := strings.Split(value, ",")
+		if options[0] == "-" {
+			return part, false, nil
+		}
+		if options[0] != "" {
+			part.name = options[0]
+		}
+		for _, o := range options[1:] {
+			if o == "required" {
+				part.required = true
+			}
+		}
+		break
+	}
+
+	switch {
+	case isFileHeader(fld.Type):
+		part.file = true
+		part.schema = &schema{Type: "string", Format: "binary"}
+	case isFileHeaders(fld.Type):
+		part.file = true
+		part.schema = &schema{Type: "array", Items: &schema{Type: "string", Format: "binary"}}
+	default:
+		part.schema, err = parseNamedType(f, fld.Type, nil)
+		if err != nil {
+			return part, false, err
+		}
+		part.file = part.schema.Format == "binary"
+	}
+	if part.file {
+		part.contentType = "application/octet-stream"
+	}
+
+	required, enum := parseValidateTag(st.Get("validate"))
+	bindingRequired, bindingEnum := parseValidateTag(st.Get("binding"))
+	if required || bindingRequired {
+		part.required = true
+	}
+	if len(bindingEnum) > 0 {
+		enum = bindingEnum
+	}
+	if len(enum) > 0 {
+		setEnum(part.schema, enum, part.name)
+	}
+
+	doc := fld.Doc.Text()
+	setExample(part.schema, fld.Doc)
+	ext, err := parseExtensions(doc)
+	if err != nil {
+		return part, false, err
+	}
+	setExtensions(part.schema, ext)
+	if m := regexpContentType.FindStringSubmatch(doc); len(m) > 0 {
+		part.contentType = strings.TrimSpace(m[1])
+	}
+
+	part.schema.Description = fieldDescription(fld)
+	return part, true, nil
+}
+
+// isFileHeader tells if expr is a multipart.FileHeader or a pointer to it
+func isFileHeader(expr ast.Expr) bool {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "FileHeader" {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "multipart"
+}
+
+// isFileHeaders tells if expr is a slice of multipart.FileHeader
+func isFileHeaders(expr ast.Expr) bool {
+	array, ok := expr.(*ast.ArrayType)
+	return ok && array.Len == nil && isFileHeader(array.Elt)
+}
@@ -0,0 +1,153 @@
+package docparser
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestExpandRequestBodies(t *testing.T) {
+	f, err := parseFile("datatest/forms.go")
+	assert.NoError(t, err)
+
+	spec := NewOpenAPI()
+	assert.Empty(t, spec.parseSchemas(f))
+	assert.Empty(t, spec.parsePaths(f))
+	assert.Empty(t, spec.expandRequestBodies())
+
+	op := spec.Paths["/pets/{id}/photos"].Operations["post"]
+	assert.Nil(t, op.RequestBodyFrom)
+
+	b, err := yaml.Marshal(op.RequestBody)
+	assert.NoError(t, err)
+	assert.Equal(t, `content:
+  multipart/form-data:
+    schema:
+      required:
+      - name
+      - photo
+      type: object
+      properties:
+        name:
+          description: Name of the photo
+          type: string
+        others:
+          type: array
+          items:
+            type: string
+            format: binary
+        photo:
+          type: string
+          format: binary
+        thumbnail:
+          type: string
+          format: binary
+    encoding:
+      others:
+        contentType: application/octet-stream
+      photo:
+        contentType: image/png, image/jpeg
+      thumbnail:
+        contentType: application/octet-stream
+`, string(b))
+}
+
+func TestExpandRequestBodiesFrom(t *testing.T) {
+	src := `package test
+
+import "mime/multipart"
+
+type Login struct {
+	User     string ` + "`form:\"user\"`" + `
+	Password string ` + "`form:\"password\"`" + `
+}
+
+type Upload struct {
+	File *multipart.FileHeader ` + "`form:\"file\"`" + `
+}
+
+// @openapi:path
+// /login:
+//	post:
+//		requestBodyFrom: Login application/x-www-form-urlencoded
+//		responses:
+//			"204":
+//				description: Logged in
+//	put:
+//		requestBodyFrom: Login
+//		requestBody:
+//			content:
+//				multipart/form-data:
+//					schema:
+//						type: object
+//		responses:
+//			"204":
+//				description: Logged in
+// /upload:
+//	post:
+//		requestBodyFrom: Upload application/x-www-form-urlencoded
+//		responses:
+//			"204":
+//				description: Uploaded
+//		callbacks:
+//			onUploaded:
+//				'{$request.body#/callbackUrl}':
+//					post:
+//						requestBodyFrom: Missing
+//						responses:
+//							"200":
+//								description: Received
+func Login() {}
+
+// @openapi:webhook loggedIn
+//	post:
+//		requestBodyFrom: Login application/x-www-form-urlencoded
+//		responses:
+//			"200":
+//				description: Received
+func SendLogin() {}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+	assert.NoError(t, err)
+
+	spec := NewOpenAPI()
+	spec.parseSchemas(f)
+	assert.Empty(t, spec.parsePaths(f))
+	assert.Empty(t, spec.parseWebhooks(f))
+	errs := spec.expandRequestBodies()
+	assert.Len(t, errs, 2)
+	assert.Equal(t, "url: /upload, verb: post", errs[0].(BuildError).Content)
+	assert.Equal(t, "url: /upload, verb: post, callback: onUploaded, url: {$request.body#/callbackUrl}, verb: post", errs[1].(BuildError).Content)
+	assert.Len(t, spec.Webhooks["loggedIn"].Operations["post"].RequestBody.Content[urlEncodedForm].Schema.Properties, 2)
+
+	b, err := yaml.Marshal(spec)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), "requestBodyFrom")
+
+	login := spec.Paths["/login"].Operations["post"].RequestBody.Content[urlEncodedForm]
+	assert.Len(t, login.Schema.Properties, 2)
+	assert.Nil(t, login.Encoding)
+
+	// the content written in the comment is kept
+	assert.Empty(t, spec.Paths["/login"].Operations["put"].RequestBody.Content[multipartForm].Schema.Properties)
+}
+
+func TestFormAnnotation(t *testing.T) {
+	form, err := formAnnotation("@openapi:form otherpackage.Upload\n")
+	assert.NoError(t, err)
+	assert.Equal(t, &formBody{Struct: "otherpackage.Upload", ContentType: multipartForm}, form)
+
+	form, err = formAnnotation("@openapi:form Login application/x-www-form-urlencoded\n")
+	assert.NoError(t, err)
+	assert.Equal(t, &formBody{Struct: "Login", ContentType: urlEncodedForm}, form)
+
+	_, err = formAnnotation("@openapi:form Login application/json\n")
+	assert.Error(t, err)
+
+	form, err = formAnnotation("no form")
+	assert.NoError(t, err)
+	assert.Nil(t, form)
+}
@@ -0,0 +1,662 @@
+package docparser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/types"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	schemaRefPrefix = "#/components/schemas/"
+
+	// DefaultGenericNameTemplate builds the schema name of an instantiated
+	// generic type, Page[Pet] becomes PagePet
+	DefaultGenericNameTemplate = "{{.Name}}{{range .Args}}{{title .}}{{end}}"
+)
+
+// genericNameData is given to the generic name template
+type genericNameData struct {
+	Name string
+	Args []string
+}
+
+var genericNameFuncs = template.FuncMap{
+	"title": func(s string) string {
+		if s == "" {
+			return s
+		}
+		r := []rune(s)
+		r[0] = unicode.ToUpper(r[0])
+		return string(r)
+	},
+}
+
+// SetGenericNameTemplate sets the text/template used to name the schemas of
+// instantiated generic types. The template receives the name of the generic
+// type as .Name and the names of the type arguments as .Args.
+func (spec *openAPI) SetGenericNameTemplate(text string) error {
+	tmpl, err := template.New("generic").Funcs(genericNameFuncs).Parse(text)
+	if err != nil {
+		return err
+	}
+	spec.genericNameTemplate = tmpl
+	return nil
+}
+
+// typeParamsCount returns the number of type parameters of a generic type
+func typeParamsCount(ts *ast.TypeSpec) int {
+	if ts.TypeParams == nil {
+		return 0
+	}
+	return ts.TypeParams.NumFields()
+}
+
+// typeExprString prints a type expression the way it is used as the real name
+// of a schema: package qualifiers are kept, they are import paths once the
+// type is qualified, see qualifyTypeExpr.
+func typeExprString(expr ast.Expr) (string, error) {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name, nil
+	case *ast.SelectorExpr:
+		x, ok := e.X.(*ast.Ident)
+		if !ok {
+			return "", fmt.Errorf("expr (%s) is unsupported as a type argument", expr)
+		}
+		return x.Name + "." + e.Sel.Name, nil
+	case *ast.StarExpr:
+		s, err := typeExprString(e.X)
+		return "*" + s, err
+	case *ast.ArrayType:
+		s, err := typeExprString(e.Elt)
+		if err != nil {
+			return "", err
+		}
+		if lit, ok := e.Len.(*ast.BasicLit); ok {
+			return "[" + lit.Value + "]" + s, nil
+		}
+		return "[]" + s, nil
+	case *ast.MapType:
+		k, err := typeExprString(e.Key)
+		if err != nil {
+			return "", err
+		}
+		v, err := typeExprString(e.Value)
+		return "map[" + k + "]" + v, err
+	case *ast.InterfaceType:
+		return "any", nil
+	case *ast.IndexExpr, *ast.IndexListExpr:
+		base, args := genericInstanceParts(e)
+		name, err := typeExprString(base)
+		if err != nil {
+			return "", err
+		}
+		strArgs := make([]string, 0, len(args))
+		for _, a := range args {
+			s, err := typeExprString(a)
+			if err != nil {
+				return "", err
+			}
+			strArgs = append(strArgs, s)
+		}
+		return name + "[" + strings.Join(strArgs, ",") + "]", nil
+	default:
+		return "", fmt.Errorf("expr (%s) is unsupported as a type argument", expr)
+	}
+}
+
+// packageName returns the name of a package from its import path, the last
+// element of the path
+func packageName(importPath string) string {
+	return importPath[strings.LastIndex(importPath, "/")+1:]
+}
+
+// importNames returns the import paths of the imports of a file by package
+// name, the name of a package is the last element of its path unless the
+// import is renamed
+func importNames(f *ast.File) map[string]string {
+	imports := make(map[string]string, len(f.Imports))
+	for _, imp := range f.Imports {
+		p, _ := strconv.Unquote(imp.Path.Value)
+		name := packageName(p)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		imports[name] = p
+	}
+	return imports
+}
+
+// qualifyTypeExpr returns a copy of a type expression where the named types
+// are qualified with the import path of their package: pkg for the types of
+// the file, the path of the import for the qualified ones. The predeclared
+// types and the type parameters params are kept as they are.
+func qualifyTypeExpr(expr ast.Expr, pkg string, imports map[string]string, params map[string]bool) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if pkg == "" || params[e.Name] || types.Universe.Lookup(e.Name) != nil {
+			return e
+		}
+		return &ast.SelectorExpr{X: ast.NewIdent(pkg), Sel: e}
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok {
+			if p, ok := imports[x.Name]; ok {
+				return &ast.SelectorExpr{X: ast.NewIdent(p), Sel: e.Sel}
+			}
+		}
+		return e
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: qualifyTypeExpr(e.X, pkg, imports, params)}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: e.Len, Elt: qualifyTypeExpr(e.Elt, pkg, imports, params)}
+	case *ast.MapType:
+		return &ast.MapType{
+			Key:   qualifyTypeExpr(e.Key, pkg, imports, params),
+			Value: qualifyTypeExpr(e.Value, pkg, imports, params),
+		}
+	case *ast.IndexExpr:
+		return &ast.IndexExpr{
+			X:     qualifyTypeExpr(e.X, pkg, imports, params),
+			Index: qualifyTypeExpr(e.Index, pkg, imports, params),
+		}
+	case *ast.IndexListExpr:
+		indices := make([]ast.Expr, 0, len(e.Indices))
+		for _, i := range e.Indices {
+			indices = append(indices, qualifyTypeExpr(i, pkg, imports, params))
+		}
+		return &ast.IndexListExpr{X: qualifyTypeExpr(e.X, pkg, imports, params), Indices: indices}
+	default:
+		return expr
+	}
+}
+
+// qualifyGenericInstances returns a copy of a type expression where the
+// instantiated generic types are qualified, see qualifyTypeExpr. The other
+// types are kept as they are, their schema is named after the type only.
+func qualifyGenericInstances(expr ast.Expr, pkg string, imports map[string]string, params map[string]bool) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.IndexExpr, *ast.IndexListExpr:
+		return qualifyTypeExpr(e, pkg, imports, params)
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: qualifyGenericInstances(e.X, pkg, imports, params)}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: e.Len, Elt: qualifyGenericInstances(e.Elt, pkg, imports, params)}
+	case *ast.MapType:
+		return &ast.MapType{
+			Key:   qualifyGenericInstances(e.Key, pkg, imports, params),
+			Value: qualifyGenericInstances(e.Value, pkg, imports, params),
+		}
+	case *ast.StructType:
+		fields := make([]*ast.Field, 0, len(e.Fields.List))
+		for _, f := range e.Fields.List {
+			fields = append(fields, &ast.Field{
+				Doc:     f.Doc,
+				Names:   f.Names,
+				Type:    qualifyGenericInstances(f.Type, pkg, imports, params),
+				Tag:     f.Tag,
+				Comment: f.Comment,
+			})
+		}
+		return &ast.StructType{Fields: &ast.FieldList{List: fields}}
+	default:
+		return expr
+	}
+}
+
+// qualifyGenericRefs rewrites the refs to instantiated generic types of the
+// document parsed from the file f with their real name, where the types are
+// qualified with their import path: Page[Pet] and Page[other.Pet] are
+// different types.
+func (spec *openAPI) qualifyGenericRefs(f *ast.File) {
+	pkg, imports := spec.pkg(f), importNames(f)
+	qualify := func(ref string) string {
+		name := strings.TrimPrefix(ref, schemaRefPrefix)
+		if !isGenericInstanceName(name) {
+			return ref
+		}
+		expr, err := parser.ParseExpr(name)
+		if err != nil {
+			return ref
+		}
+		switch expr.(type) {
+		case *ast.IndexExpr, *ast.IndexListExpr:
+		default:
+			return ref
+		}
+		realName, err := typeExprString(qualifyTypeExpr(expr, pkg, imports, nil))
+		if err != nil {
+			return ref
+		}
+		return schemaRefPrefix + realName
+	}
+
+	for _, s := range spec.registeredSchemas {
+		mapSchemaRefs(s, qualify)
+	}
+	spec.mapPathsRefs(qualify)
+	spec.mapComponentsRefs(qualify)
+}
+
+// regexpQualifiedType matches the types qualified with an import path in the
+// real name of an instantiated generic type, i.e. github.com/user/pets.Pet
+var regexpQualifiedType = regexp.MustCompile(`([\w~-]+(?:[./][\w~-]+)*)\.(\w+)`)
+
+// parseGenericName parses the real name of an instantiated generic type, a
+// type qualified with an import path is a selector on an identifier holding
+// the import path
+func parseGenericName(name string) (ast.Expr, error) {
+	paths := []string{}
+	src := regexpQualifiedType.ReplaceAllStringFunc(name, func(s string) string {
+		m := regexpQualifiedType.FindStringSubmatch(s)
+		paths = append(paths, m[1])
+		return fmt.Sprintf("_%d.%s", len(paths)-1, m[2])
+	})
+	expr, err := parser.ParseExpr(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid type %s: %w", name, err)
+	}
+
+	ast.Inspect(expr, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if x, ok := sel.X.(*ast.Ident); ok && strings.HasPrefix(x.Name, "_") {
+			if i, err := strconv.Atoi(x.Name[1:]); err == nil && i < len(paths) {
+				sel.X = ast.NewIdent(paths[i])
+			}
+		}
+		return true
+	})
+	return expr, nil
+}
+
+// genericInstanceParts splits an instantiated generic type into its generic
+// type and its type arguments
+func genericInstanceParts(expr ast.Expr) (ast.Expr, []ast.Expr) {
+	switch e := expr.(type) {
+	case *ast.IndexExpr:
+		return e.X, []ast.Expr{e.Index}
+	case *ast.IndexListExpr:
+		return e.X, e.Indices
+	}
+	return expr, nil
+}
+
+// isGenericInstanceName tells if a schema real name is an instantiated generic
+// type, i.e. Page[Pet]
+func isGenericInstanceName(name string) bool {
+	return strings.Contains(name, "[") && !strings.HasPrefix(name, "[")
+}
+
+// genericArgName returns the name of a type argument used by the name template
+func (spec *openAPI) genericArgName(expr ast.Expr) (string, error) {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name, nil
+	case *ast.SelectorExpr:
+		return e.Sel.Name, nil
+	case *ast.StarExpr:
+		return spec.genericArgName(e.X)
+	case *ast.ArrayType:
+		s, err := spec.genericArgName(e.Elt)
+		return s + "List", err
+	case *ast.MapType:
+		s, err := spec.genericArgName(e.Value)
+		return s + "Map", err
+	case *ast.InterfaceType:
+		return "AnyValue", nil
+	case *ast.IndexExpr, *ast.IndexListExpr:
+		return spec.genericSchemaName(e)
+	default:
+		return "", fmt.Errorf("expr (%s) is unsupported as a type argument", expr)
+	}
+}
+
+// genericSchemaName computes the component name of an instantiated generic
+// type with the name template
+func (spec *openAPI) genericSchemaName(expr ast.Expr) (string, error) {
+	base, args := genericInstanceParts(expr)
+	data := genericNameData{}
+
+	name, err := typeExprString(base)
+	if err != nil {
+		return "", err
+	}
+	_, data.Name = splitTypeKey(name)
+
+	for _, a := range args {
+		s, err := spec.genericArgName(a)
+		if err != nil {
+			return "", err
+		}
+		data.Args = append(data.Args, s)
+	}
+
+	buf := bytes.Buffer{}
+	if err := spec.genericNameTemplate.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// substituteTypeParams returns a copy of expr where the type parameters are
+// replaced by their type arguments
+func substituteTypeParams(expr ast.Expr, args map[string]ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if a, ok := args[e.Name]; ok {
+			return a
+		}
+		return e
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: substituteTypeParams(e.X, args)}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: e.Len, Elt: substituteTypeParams(e.Elt, args)}
+	case *ast.MapType:
+		return &ast.MapType{
+			Key:   substituteTypeParams(e.Key, args),
+			Value: substituteTypeParams(e.Value, args),
+		}
+	case *ast.IndexExpr:
+		return &ast.IndexExpr{X: e.X, Index: substituteTypeParams(e.Index, args)}
+	case *ast.IndexListExpr:
+		indices := make([]ast.Expr, 0, len(e.Indices))
+		for _, i := range e.Indices {
+			indices = append(indices, substituteTypeParams(i, args))
+		}
+		return &ast.IndexListExpr{X: e.X, Indices: indices}
+	case *ast.StructType:
+		fields := make([]*ast.Field, 0, len(e.Fields.List))
+		for _, f := range e.Fields.List {
+			fields = append(fields, &ast.Field{
+				Doc:     f.Doc,
+				Names:   f.Names,
+				Type:    substituteTypeParams(f.Type, args),
+				Tag:     f.Tag,
+				Comment: f.Comment,
+			})
+		}
+		return &ast.StructType{Fields: &ast.FieldList{List: fields}}
+	default:
+		// qualified identifiers and other expressions can't be type parameters
+		return expr
+	}
+}
+
+// mapSchemaRefs calls fn on every $ref found in a schema and replaces the ref
+// with the returned value
+func mapSchemaRefs(v interface{}, fn func(string) string) {
+	switch s := v.(type) {
+	case *schema:
+		if s == nil {
+			return
+		}
+		if s.Ref != "" {
+			s.Ref = fn(s.Ref)
+		}
+		for _, p := range s.Properties {
+			mapSchemaRefs(p, fn)
+		}
+		mapSchemaRefs(s.AdditionalProperties, fn)
+		mapSchemaRefs(s.Items, fn)
+		for _, p := range s.AllOf {
+			mapSchemaRefs(p, fn)
+		}
+		for _, p := range s.OneOf {
+			mapSchemaRefs(p, fn)
+		}
+		for _, p := range s.AnyOf {
+			mapSchemaRefs(p, fn)
+		}
+		mapSchemaRefs(s.Not, fn)
+	case *composedSchema:
+		if s == nil {
+			return
+		}
+		for _, p := range s.AllOf {
+			mapSchemaRefs(p, fn)
+		}
+	case map[string]*schema:
+		for _, p := range s {
+			mapSchemaRefs(p, fn)
+		}
+	}
+}
+
+// mapPathsRefs calls mapSchemaRefs on every schema of the paths and the
+// webhooks
+func (spec *openAPI) mapPathsRefs(fn func(string) string) {
+	mapPathItemsRefs(spec.Paths, fn)
+	mapPathItemsRefs(spec.Webhooks, fn)
+}
+
+// mapPathItemsRefs calls mapSchemaRefs on every schema of path items, the
+// callbacks of the operations included
+func mapPathItemsRefs(items paths, fn func(string) string) {
+	for _, p := range items {
+		mapParametersRefs(p.Parameters, fn)
+		for _, op := range p.Operations {
+			mapParametersRefs(op.Parameters, fn)
+			mapContentRefs(op.RequestBody.Content, fn)
+			for _, r := range op.Responses {
+				mapContentRefs(r.Content, fn)
+				mapHeadersRefs(r.Headers, fn)
+			}
+			mapHeadersRefs(op.Headers, fn)
+			for _, c := range op.Callbacks {
+				mapPathItemsRefs(c, fn)
+			}
+		}
+	}
+}
+
+func mapParametersRefs(params []parameter, fn func(string) string) {
+	for _, p := range params {
+		mapSchemaRefs(p.Schema, fn)
+		mapContentRefs(p.Content, fn)
+	}
+}
+
+func mapContentRefs(contents map[string]content, fn func(string) string) {
+	for _, c := range contents {
+		mapSchemaRefs(c.Schema, fn)
+		for _, e := range c.Encoding {
+			mapHeadersRefs(e.Headers, fn)
+		}
+	}
+}
+
+func mapHeadersRefs(headers map[string]header, fn func(string) string) {
+	for _, h := range headers {
+		mapSchemaRefs(h.Schema, fn)
+		mapContentRefs(h.Content, fn)
+	}
+}
+
+// canonicalGenericRef rewrites a ref to an instantiated generic type written
+// by hand in a comment, i.e. Page[ otherpackage.Pet ], with its real name.
+// The pointers of the type arguments are removed, Page[*Pet] and Page[Pet]
+// are the same schema.
+func canonicalGenericRef(ref string) (string, error) {
+	name := strings.TrimPrefix(ref, schemaRefPrefix)
+	expr, err := parseGenericName(name)
+	if err != nil {
+		return "", err
+	}
+	derefTypeArgs(expr)
+	realName, err := typeExprString(expr)
+	if err != nil {
+		return "", err
+	}
+	return schemaRefPrefix + realName, nil
+}
+
+// derefTypeArgs removes the pointers from the type arguments of an
+// instantiated generic type, and of the generic types nested in them
+func derefTypeArgs(expr ast.Expr) {
+	deref := func(e ast.Expr) ast.Expr {
+		for {
+			star, ok := e.(*ast.StarExpr)
+			if !ok {
+				return e
+			}
+			e = star.X
+		}
+	}
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch e := n.(type) {
+		case *ast.IndexExpr:
+			e.Index = deref(e.Index)
+		case *ast.IndexListExpr:
+			for i := range e.Indices {
+				e.Indices[i] = deref(e.Indices[i])
+			}
+		}
+		return true
+	})
+}
+
+// instantiateGenerics registers a schema for every instantiated generic type
+// referenced by the registered schemas and the paths
+func (spec *openAPI) instantiateGenerics() (errs []error) {
+	queue := []string{}
+	collect := func(ref string) string {
+		if !strings.HasPrefix(ref, schemaRefPrefix) ||
+			!isGenericInstanceName(strings.TrimPrefix(ref, schemaRefPrefix)) {
+			return ref
+		}
+		canonical, err := canonicalGenericRef(ref)
+		if err != nil {
+			logrus.WithError(err).WithField("ref", ref).Error("Can't parse generic type reference")
+			errs = append(errs, BuildError{
+				Err:     err,
+				Content: ref,
+				Message: "can't parse generic type reference",
+			})
+			return ref
+		}
+		queue = append(queue, strings.TrimPrefix(canonical, schemaRefPrefix))
+		return canonical
+	}
+
+	for _, s := range spec.registeredSchemas {
+		mapSchemaRefs(s, collect)
+	}
+	spec.mapPathsRefs(collect)
+	spec.mapComponentsRefs(collect)
+
+	// the real names of the instances by schema name, the types of two
+	// packages can get the same name
+	schemaNames := make(map[string]string)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if _, ok := spec.registeredSchemas[name]; ok {
+			continue
+		}
+
+		entity, err := spec.instantiateGeneric(name)
+		if err != nil {
+			logrus.WithError(err).WithField("name", name).Error("Can't instantiate generic type")
+			errs = append(errs, BuildError{
+				Err:     err,
+				Content: name,
+				Message: "can't instantiate generic type",
+			})
+			continue
+		}
+		if mtd, ok := entity.(metaSchema); ok {
+			if other, ok := schemaNames[mtd.CustomName()]; ok && !reflect.DeepEqual(spec.registeredSchemas[other], entity) {
+				err := fmt.Errorf("%s and %s are both named %s", other, name, mtd.CustomName())
+				logrus.WithError(err).WithField("name", name).Error("Generic types have the same schema name")
+				errs = append(errs, BuildError{
+					Err:     err,
+					Content: name,
+					Message: "generic types have the same schema name",
+				})
+			}
+			schemaNames[mtd.CustomName()] = name
+		}
+		spec.registeredSchemas[name] = entity
+		mapSchemaRefs(entity, collect)
+	}
+	return errs
+}
+
+// instantiateGeneric builds the schema of an instantiated generic type from
+// its real name
+func (spec *openAPI) instantiateGeneric(name string) (interface{}, error) {
+	expr, err := parseGenericName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if genericDepth(expr) > maxGenericDepth {
+		return nil, fmt.Errorf("instantiation cycle, type arguments nested more than %d times", maxGenericDepth)
+	}
+
+	base, args := genericInstanceParts(expr)
+	baseName, err := typeExprString(base)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := spec.typeKey(baseName)
+	if err != nil {
+		return nil, fmt.Errorf("generic type %s not found", baseName)
+	}
+	gt, ok := spec.typeDecl(key)
+	if !ok || typeParamsCount(gt.spec) == 0 {
+		return nil, fmt.Errorf("generic type %s not found", baseName)
+	}
+
+	params := []string{}
+	isParam := make(map[string]bool)
+	for _, f := range gt.spec.TypeParams.List {
+		for _, n := range f.Names {
+			params = append(params, n.Name)
+			isParam[n.Name] = true
+		}
+	}
+	if len(params) != len(args) {
+		return nil, fmt.Errorf("generic type %s expects %d type arguments, got %d", baseName, len(params), len(args))
+	}
+
+	substitutions := make(map[string]ast.Expr, len(params))
+	for i, p := range params {
+		substitutions[p] = args[i]
+	}
+
+	schemaName, err := spec.genericSchemaName(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	// the generic types used by the declaration are qualified in its package
+	pkg, _ := splitTypeKey(key)
+	typeExpr := qualifyGenericInstances(gt.spec.Type, pkg, importNames(gt.file), isParam)
+	entity, errs := spec.parseSchemaType(gt.file, substituteTypeParams(typeExpr, substitutions), schemaName)
+	if entity == nil {
+		if len(errs) > 0 {
+			return nil, errs[0]
+		}
+		return nil, errors.New("can't parse generic type")
+	}
+
+	if mtd, ok := entity.(metaSchema); ok {
+		mtd.SetCustomName(schemaName)
+	}
+	return entity, nil
+}
@@ -0,0 +1,276 @@
+package docparser
+
+import (
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const genericsSource = `package test
+
+type Page[T any] struct {
+	Items []T    ` + "`json:\"items\"`" + `
+	Next  string ` + "`json:\"next\"`" + `
+}
+
+type Pair[K any, V any] struct {
+	Key   K ` + "`json:\"key\"`" + `
+	Value V ` + "`json:\"value\"`" + `
+}
+
+// @openapi:schema
+type Pet struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+
+// @openapi:schema
+type Listing struct {
+	Pets   Page[Pet]               ` + "`json:\"pets\"`" + `
+	Nested Page[Page[*Pet]]        ` + "`json:\"nested\"`" + `
+	Pairs  []Pair[string, []Pet]   ` + "`json:\"pairs\"`" + `
+}
+
+// @openapi:path
+// /pets:
+//   get:
+//     responses:
+//       "200":
+//         content:
+//           application/json:
+//             schema:
+//               $ref: "#/components/schemas/Page[ Pet ]"
+func List() {}
+`
+
+type instantiateGenericsTestCase struct {
+	description     string
+	template        string
+	expectedSchemas map[string]string
+	expectedRef     string
+}
+
+func TestInstantiateGenerics(t *testing.T) {
+	testCases := []instantiateGenericsTestCase{
+		{
+			description: "Should instantiate generic types with the default name template",
+			template:    DefaultGenericNameTemplate,
+			expectedSchemas: map[string]string{
+				"PagePet": `type: object
+properties:
+  items:
+    type: array
+    items:
+      $ref: '#/components/schemas/Pet'
+  next:
+    type: string
+`,
+				"PagePagePet": `type: object
+properties:
+  items:
+    type: array
+    items:
+      $ref: '#/components/schemas/PagePet'
+  next:
+    type: string
+`,
+				"PairStringPetList": `type: object
+properties:
+  key:
+    type: string
+  value:
+    type: array
+    items:
+      $ref: '#/components/schemas/Pet'
+`,
+			},
+			expectedRef: "#/components/schemas/PagePet",
+		},
+		{
+			description: "Should instantiate generic types with a custom name template",
+			template:    `{{.Name}}Of{{range .Args}}{{.}}{{end}}`,
+			expectedSchemas: map[string]string{
+				"PageOfPet":           "",
+				"PageOfPageOfPet":     "",
+				"PairOfstringPetList": "",
+			},
+			expectedRef: "#/components/schemas/PageOfPet",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			f, err := parser.ParseFile(token.NewFileSet(), "", genericsSource, parser.ParseComments)
+			assert.NoError(t, err)
+
+			spec := NewOpenAPI()
+			assert.NoError(t, spec.SetGenericNameTemplate(tc.template))
+			assert.Empty(t, spec.parseSchemas(f))
+			assert.Empty(t, spec.parsePaths(f))
+			assert.Empty(t, spec.instantiateGenerics())
+			spec.composeSpecSchemas()
+
+			for name, expected := range tc.expectedSchemas {
+				s, ok := spec.Components.Schemas[name]
+				if !assert.True(t, ok, "schema %s not found", name) || expected == "" {
+					continue
+				}
+				b, err := yaml.Marshal(s)
+				assert.NoError(t, err)
+				assert.Equal(t, expected, string(b))
+			}
+
+			ref := spec.Paths["/pets"].Operations["get"].Responses["200"].Content["application/json"].Schema.Ref
+			assert.Equal(t, tc.expectedRef, ref)
+		})
+	}
+}
+
+func TestInstantiateGenericsPointers(t *testing.T) {
+	src := `package test
+
+type Box[T any] struct {
+	Value T ` + "`json:\"value\"`" + `
+}
+
+type Page[T any] struct {
+	Items []T    ` + "`json:\"items\"`" + `
+	Inner Box[T] ` + "`json:\"inner\"`" + `
+}
+
+// @openapi:schema
+type Pet struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+
+// @openapi:schema
+type Listing struct {
+	Pets     Page[Pet]  ` + "`json:\"pets\"`" + `
+	Pointers Page[*Pet] ` + "`json:\"pointers\"`" + `
+}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+	assert.NoError(t, err)
+
+	spec := NewOpenAPI()
+	assert.Empty(t, spec.parseSchemas(f))
+	// Page[*Pet] is the same instance as Page[Pet], even with a generic field
+	assert.Empty(t, spec.instantiateGenerics())
+	assert.Contains(t, spec.registeredSchemas, "Page[Pet]")
+	assert.NotContains(t, spec.registeredSchemas, "Page[*Pet]")
+	spec.composeSpecSchemas()
+	assert.Contains(t, spec.Components.Schemas, "BoxPet")
+
+	listing := spec.Components.Schemas["Listing"].(*schema)
+	assert.Equal(t, "#/components/schemas/PagePet", listing.Properties["pets"].Ref)
+	assert.Equal(t, "#/components/schemas/PagePet", listing.Properties["pointers"].Ref)
+}
+
+func TestInstantiateGenericsErrors(t *testing.T) {
+	src := `package test
+
+type Page[T any] struct {
+	Items []T
+}
+
+// @openapi:schema
+type Listing struct {
+	Unknown Box[string] ` + "`json:\"unknown\"`" + `
+	Invalid Page[string, int] ` + "`json:\"invalid\"`" + `
+}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+	assert.NoError(t, err)
+
+	spec := NewOpenAPI()
+	spec.parseSchemas(f)
+	errs := spec.instantiateGenerics()
+	assert.Len(t, errs, 2)
+}
+
+func TestInstantiateGenericsPackages(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, map[string]string{
+		filepath.Join(dir, "go.mod"): "module example.com/pets\n",
+		filepath.Join(dir, "a", "a.go"): `package a
+
+// @openapi:schema
+type Pet struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+
+type Page[T any] struct {
+	Items []T ` + "`json:\"items\"`" + `
+}
+`,
+		filepath.Join(dir, "b", "b.go"): `package b
+
+// @openapi:schema
+type Animal struct {
+	Legs int ` + "`json:\"legs\"`" + `
+}
+
+type Page[T any] struct {
+	Data T ` + "`json:\"data\"`" + `
+}
+`,
+		filepath.Join(dir, "listing.go"): `package pets
+
+import (
+	"example.com/pets/a"
+	"example.com/pets/b"
+)
+
+// @openapi:schema
+type Listing struct {
+	Pets    a.Page[a.Pet]    ` + "`json:\"pets\"`" + `
+	Animals a.Page[b.Animal] ` + "`json:\"animals\"`" + `
+	Single  b.Page[b.Animal] ` + "`json:\"single\"`" + `
+}
+`,
+	})
+
+	spec := NewOpenAPI()
+	spec.Parse([]string{dir}, nil, "vendor", false)
+
+	for _, name := range []string{
+		"example.com/pets/a.Page[example.com/pets/a.Pet]",
+		"example.com/pets/a.Page[example.com/pets/b.Animal]",
+		"example.com/pets/b.Page[example.com/pets/b.Animal]",
+	} {
+		assert.Contains(t, spec.registeredSchemas, name)
+	}
+
+	b, err := yaml.Marshal(spec.Components.Schemas["PagePet"])
+	assert.NoError(t, err)
+	assert.Equal(t, `type: object
+properties:
+  items:
+    type: array
+    items:
+      $ref: '#/components/schemas/Pet'
+`, string(b))
+
+	// the instances of a.Page and b.Page get the same schema name
+	if assert.Len(t, spec.Errors(), 1) {
+		assert.Equal(t, "generic types have the same schema name", spec.Errors()[0].(BuildError).Message)
+	}
+}
+
+func TestParseGenericName(t *testing.T) {
+	for _, name := range []string{
+		"Page[Pet]",
+		"pets.Page[*time.Time]",
+		"example.com/pets.Page[[]map[string]gopkg.in/yaml.v2.MapSlice]",
+		"github.com/user/pets.Pair[string,github.com/user/pets/a.Page[github.com/user/pets/b.Pet]]",
+	} {
+		expr, err := parseGenericName(name)
+		assert.NoError(t, err, name)
+		s, err := typeExprString(expr)
+		assert.NoError(t, err, name)
+		assert.Equal(t, name, s)
+	}
+}
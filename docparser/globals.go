@@ -0,0 +1,253 @@
+package docparser
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"reflect"
+	"regexp"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	regexpSecurityScheme = regexp.MustCompile(`@openapi:securityScheme (\w+)\n([^@]*)$`)
+	regexpSecurity       = regexp.MustCompile("@openapi:security\n([^@]*)$")
+	regexpServer         = regexp.MustCompile("@openapi:server\n([^@]*)$")
+	regexpTag            = regexp.MustCompile("@openapi:tag\n([^@]*)$")
+	regexpTagGroup       = regexp.MustCompile("@openapi:tagGroup\n([^@]*)$")
+)
+
+type tagGroup struct {
+	Name string   `yaml:"name"`
+	Tags []string `yaml:"tags"`
+}
+
+// validate checks the fields required by the type of the security scheme
+func (s securitySchemes) validate() error {
+	switch s.Type {
+	case "apiKey":
+		if s.Name == "" {
+			return errors.New("apiKey security scheme requires a name")
+		}
+		if s.In != "query" && s.In != "header" && s.In != "cookie" {
+			return fmt.Errorf("apiKey security scheme can't be in %q", s.In)
+		}
+	case "http":
+		if s.Scheme == "" {
+			return errors.New("http security scheme requires a scheme")
+		}
+	case "oauth2":
+		if len(s.Flows) == 0 {
+			return errors.New("oauth2 security scheme requires flows")
+		}
+		for name, f := range s.Flows {
+			if err := f.validate(name); err != nil {
+				return err
+			}
+		}
+	case "openIdConnect":
+		if s.OpenIDConnectURL == "" {
+			return errors.New("openIdConnect security scheme requires an openIdConnectUrl")
+		}
+	default:
+		return fmt.Errorf("unknown security scheme type %q", s.Type)
+	}
+	return nil
+}
+
+// validate checks the urls required by an oauth2 flow
+func (f flow) validate(name string) error {
+	needsAuthorization, needsToken := false, false
+	switch name {
+	case "implicit":
+		needsAuthorization = true
+	case "password", "clientCredentials":
+		needsToken = true
+	case "authorizationCode":
+		needsAuthorization, needsToken = true, true
+	default:
+		return fmt.Errorf("unknown oauth2 flow %q", name)
+	}
+
+	if needsAuthorization && f.AuthorizationURL == "" {
+		return fmt.Errorf("oauth2 %s flow requires an authorizationUrl", name)
+	}
+	if needsToken && f.TokenURL == "" {
+		return fmt.Errorf("oauth2 %s flow requires a tokenUrl", name)
+	}
+	return nil
+}
+
+// unmarshalOneOrMany unmarshals a yaml list or a single object into the slice
+// pointed by list
+func unmarshalOneOrMany(content string, list interface{}) error {
+	if err := yaml.Unmarshal([]byte(content), list); err == nil {
+		return nil
+	}
+
+	lv := reflect.ValueOf(list).Elem()
+	item := reflect.New(lv.Type().Elem())
+	if err := yaml.Unmarshal([]byte(content), item.Interface()); err != nil {
+		return err
+	}
+	lv.Set(reflect.Append(lv, item.Elem()))
+	return nil
+}
+
+// parseGlobals parses the security schemes, the security requirements, the
+// servers, the tags and the tag groups of the document
+func (spec *openAPI) parseGlobals(f *ast.File) (errs []error) {
+	fail := func(err error, content, message string) {
+		logrus.
+			WithError(err).
+			WithField("content", content).
+			Error(message)
+		errs = append(errs, &BuildError{
+			Err:     err,
+			Content: content,
+			Message: message,
+		})
+	}
+
+	for _, s := range f.Comments {
+		t := s.Text()
+
+		if a := regexpSecurityScheme.FindStringSubmatch(t); len(a) > 0 {
+			name, content := a[1], tab.ReplaceAllString(a[2], "  ")
+			scheme := securitySchemes{}
+			if err := yaml.Unmarshal([]byte(content), &scheme); err != nil {
+				fail(err, content, "unable to unmarshal security scheme")
+				continue
+			}
+			if err := scheme.validate(); err != nil {
+				fail(err, content, "invalid security scheme")
+				continue
+			}
+			if err := spec.addSecurityScheme(name, scheme); err != nil {
+				fail(err, name, "security scheme already exists and is different")
+				continue
+			}
+			logrus.WithField("name", name).Info("Parsing security scheme")
+		}
+
+		if a := regexpSecurity.FindStringSubmatch(t); len(a) > 0 {
+			content := tab.ReplaceAllString(a[1], "  ")
+			requirements := []map[string][]string{}
+			if err := unmarshalOneOrMany(content, &requirements); err != nil {
+				fail(err, content, "unable to unmarshal security")
+				continue
+			}
+			for _, r := range requirements {
+				spec.addSecurity(r)
+			}
+			logrus.Info("Parsing security")
+		}
+
+		if a := regexpServer.FindStringSubmatch(t); len(a) > 0 {
+			content := tab.ReplaceAllString(a[1], "  ")
+			servers := []server{}
+			if err := unmarshalOneOrMany(content, &servers); err != nil {
+				fail(err, content, "unable to unmarshal server")
+				continue
+			}
+			for _, srv := range servers {
+				if err := spec.addServer(srv); err != nil {
+					fail(err, srv.URL, "server already exists and is different")
+					continue
+				}
+				logrus.WithField("url", srv.URL).Info("Parsing server")
+			}
+		}
+
+		if a := regexpTag.FindStringSubmatch(t); len(a) > 0 {
+			content := tab.ReplaceAllString(a[1], "  ")
+			tags := []tag{}
+			if err := unmarshalOneOrMany(content, &tags); err != nil {
+				fail(err, content, "unable to unmarshal tag")
+				continue
+			}
+			for _, tg := range tags {
+				if err := spec.addTag(tg); err != nil {
+					fail(err, tg.Name, "tag already exists and is different")
+					continue
+				}
+				logrus.WithField("name", tg.Name).Info("Parsing tag")
+			}
+		}
+
+		if a := regexpTagGroup.FindStringSubmatch(t); len(a) > 0 {
+			content := tab.ReplaceAllString(a[1], "  ")
+			groups := []tagGroup{}
+			if err := unmarshalOneOrMany(content, &groups); err != nil {
+				fail(err, content, "unable to unmarshal tag group")
+				continue
+			}
+			for _, g := range groups {
+				if err := spec.addTagGroup(g); err != nil {
+					fail(err, g.Name, "tag group already exists and is different")
+					continue
+				}
+				logrus.WithField("name", g.Name).Info("Parsing tag group")
+			}
+		}
+	}
+	return
+}
+
+func (spec *openAPI) addSecurityScheme(name string, scheme securitySchemes) error {
+	return addComponent(spec.Components.components("securityScheme"), name, scheme)
+}
+
+func (spec *openAPI) addSecurity(requirement map[string][]string) {
+	for _, r := range spec.Security {
+		if reflect.DeepEqual(r, requirement) {
+			return
+		}
+	}
+	spec.Security = append(spec.Security, requirement)
+}
+
+func (spec *openAPI) addServer(srv server) error {
+	for _, s := range spec.Servers {
+		if s.URL != srv.URL {
+			continue
+		}
+		if !reflect.DeepEqual(s, srv) {
+			return errors.New("server already exists and is different")
+		}
+		return nil
+	}
+	spec.Servers = append(spec.Servers, srv)
+	return nil
+}
+
+func (spec *openAPI) addTag(tg tag) error {
+	for _, t := range spec.Tags {
+		if t.Name != tg.Name {
+			continue
+		}
+		if !reflect.DeepEqual(t, tg) {
+			return errors.New("tag already exists and is different")
+		}
+		return nil
+	}
+	spec.Tags = append(spec.Tags, tg)
+	return nil
+}
+
+func (spec *openAPI) addTagGroup(g tagGroup) error {
+	for _, group := range spec.XGroupTags {
+		current, ok := group.(tagGroup)
+		if !ok || current.Name != g.Name {
+			continue
+		}
+		if !reflect.DeepEqual(current, g) {
+			return errors.New("tag group already exists and is different")
+		}
+		return nil
+	}
+	spec.XGroupTags = append(spec.XGroupTags, g)
+	return nil
+}
@@ -0,0 +1,156 @@
+package docparser
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const globalsSource = `package test
+
+// @openapi:securityScheme api_key
+//	type: apiKey
+//	name: X-API-Key
+//	in: header
+
+// @openapi:securityScheme bearer
+//	type: http
+//	scheme: bearer
+//	bearerFormat: JWT
+
+// @openapi:securityScheme oidc
+//	type: openIdConnect
+//	openIdConnectUrl: https://example.com/.well-known/openid-configuration
+
+// @openapi:securityScheme petstore_auth
+//	type: oauth2
+//	flows:
+//		implicit:
+//			authorizationUrl: https://example.com/authorize
+//			scopes:
+//				read:pets: read your pets
+//		password:
+//			tokenUrl: https://example.com/token
+//			refreshUrl: https://example.com/refresh
+//			scopes: {}
+//		clientCredentials:
+//			tokenUrl: https://example.com/token
+//			scopes: {}
+//		authorizationCode:
+//			authorizationUrl: https://example.com/authorize
+//			tokenUrl: https://example.com/token
+//			scopes: {}
+
+// @openapi:security
+//	- api_key: []
+//	- petstore_auth:
+//		- read:pets
+
+// @openapi:server
+//	url: https://api.example.com
+//	description: Production
+
+// @openapi:server
+//	- url: https://api.example.com
+//	  description: Production
+//	- url: https://staging.example.com
+//	  description: Staging
+
+// @openapi:tag
+//	name: pet
+//	description: Everything about your pets
+
+// @openapi:tagGroup
+//	name: Animals
+//	tags:
+//		- pet
+`
+
+func TestParseGlobals(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "", globalsSource, parser.ParseComments)
+	assert.NoError(t, err)
+
+	spec := NewOpenAPI()
+	assert.Empty(t, spec.parseGlobals(f))
+
+	assert.Len(t, spec.Components.SecuritySchemes, 4)
+	assert.Equal(t, "JWT", spec.Components.SecuritySchemes["bearer"].BearerFormat)
+	assert.Equal(t, "https://example.com/refresh", spec.Components.SecuritySchemes["petstore_auth"].Flows["password"].RefreshURL)
+	assert.Equal(t, []map[string][]string{
+		{"api_key": {}},
+		{"petstore_auth": {"read:pets"}},
+	}, spec.Security)
+	assert.Len(t, spec.Servers, 2)
+	assert.Equal(t, []tag{{Name: "pet", Description: "Everything about your pets"}}, spec.Tags)
+	assert.Equal(t, []interface{}{tagGroup{Name: "Animals", Tags: []string{"pet"}}}, spec.XGroupTags)
+
+	b, err := yaml.Marshal(spec.Components.SecuritySchemes["petstore_auth"].Flows["implicit"])
+	assert.NoError(t, err)
+	assert.Equal(t, `authorizationUrl: https://example.com/authorize
+scopes:
+  read:pets: read your pets
+`, string(b))
+}
+
+type parseGlobalsErrorsTestCase struct {
+	description string
+	comment     string
+}
+
+func TestParseGlobalsErrors(t *testing.T) {
+	testCases := []parseGlobalsErrorsTestCase{
+		{
+			description: "Should fail with an apiKey security scheme without name",
+			comment: `// @openapi:securityScheme key
+//	type: apiKey
+//	in: header`,
+		},
+		{
+			description: "Should fail with an oauth2 flow without tokenUrl",
+			comment: `// @openapi:securityScheme oauth
+//	type: oauth2
+//	flows:
+//		password:
+//			scopes: {}`,
+		},
+		{
+			description: "Should fail with an unknown security scheme type",
+			comment: `// @openapi:securityScheme basic
+//	type: basic`,
+		},
+		{
+			description: "Should fail with a different security scheme with the same name",
+			comment: `// @openapi:securityScheme bearer
+//	type: http
+//	scheme: basic`,
+		},
+		{
+			description: "Should fail with a different server with the same url",
+			comment: `// @openapi:server
+//	url: https://api.example.com
+//	description: Other`,
+		},
+		{
+			description: "Should fail with a different tag with the same name",
+			comment: `// @openapi:tag
+//	name: pet
+//	description: Other`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			spec := NewOpenAPI()
+			f, err := parser.ParseFile(token.NewFileSet(), "", globalsSource, parser.ParseComments)
+			assert.NoError(t, err)
+			assert.Empty(t, spec.parseGlobals(f))
+
+			f, err = parser.ParseFile(token.NewFileSet(), "", "package test\n\n"+tc.comment+"\n", parser.ParseComments)
+			assert.NoError(t, err)
+			assert.Len(t, spec.parseGlobals(f), 1)
+		})
+	}
+}
@@ -0,0 +1,744 @@
+package docparser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// statusCodes are the status codes of the constants of net/http
+var statusCodes = map[string]int{
+	"StatusContinue":                      100,
+	"StatusSwitchingProtocols":            101,
+	"StatusProcessing":                    102,
+	"StatusEarlyHints":                    103,
+	"StatusOK":                            200,
+	"StatusCreated":                       201,
+	"StatusAccepted":                      202,
+	"StatusNonAuthoritativeInfo":          203,
+	"StatusNoContent":                     204,
+	"StatusResetContent":                  205,
+	"StatusPartialContent":                206,
+	"StatusMultiStatus":                   207,
+	"StatusAlreadyReported":               208,
+	"StatusIMUsed":                        226,
+	"StatusMultipleChoices":               300,
+	"StatusMovedPermanently":              301,
+	"StatusFound":                         302,
+	"StatusSeeOther":                      303,
+	"StatusNotModified":                   304,
+	"StatusUseProxy":                      305,
+	"StatusTemporaryRedirect":             307,
+	"StatusPermanentRedirect":             308,
+	"StatusBadRequest":                    400,
+	"StatusUnauthorized":                  401,
+	"StatusPaymentRequired":               402,
+	"StatusForbidden":                     403,
+	"StatusNotFound":                      404,
+	"StatusMethodNotAllowed":              405,
+	"StatusNotAcceptable":                 406,
+	"StatusProxyAuthRequired":             407,
+	"StatusRequestTimeout":                408,
+	"StatusConflict":                      409,
+	"StatusGone":                          410,
+	"StatusLengthRequired":                411,
+	"StatusPreconditionFailed":            412,
+	"StatusRequestEntityTooLarge":         413,
+	"StatusRequestURITooLong":             414,
+	"StatusUnsupportedMediaType":          415,
+	"StatusRequestedRangeNotSatisfiable":  416,
+	"StatusExpectationFailed":             417,
+	"StatusTeapot":                        418,
+	"StatusMisdirectedRequest":            421,
+	"StatusUnprocessableEntity":           422,
+	"StatusLocked":                        423,
+	"StatusFailedDependency":              424,
+	"StatusTooEarly":                      425,
+	"StatusUpgradeRequired":               426,
+	"StatusPreconditionRequired":          428,
+	"StatusTooManyRequests":               429,
+	"StatusRequestHeaderFieldsTooLarge":   431,
+	"StatusUnavailableForLegalReasons":    451,
+	"StatusInternalServerError":           500,
+	"StatusNotImplemented":                501,
+	"StatusBadGateway":                    502,
+	"StatusServiceUnavailable":            503,
+	"StatusGatewayTimeout":                504,
+	"StatusHTTPVersionNotSupported":       505,
+	"StatusVariantAlsoNegotiates":         506,
+	"StatusInsufficientStorage":           507,
+	"StatusLoopDetected":                  508,
+	"StatusNotExtended":                   510,
+	"StatusNetworkAuthenticationRequired": 511,
+}
+
+// handler is an operation documented on a function, the body of the function
+// is compared with the responses of the operation once every file is parsed
+type handler struct {
+	pkg  string
+	file *ast.File
+	decl *ast.FuncDecl
+	url  string
+	verb string
+	// path and index locate the declaration of a handler read from the cache
+	path  string
+	index int
+}
+
+// funcDecl is a function declared in a parsed file, kept to follow the calls
+// of the handlers to the helpers of their package
+type funcDecl struct {
+	file *ast.File
+	decl *ast.FuncDecl
+	// path is the file of a function read from the cache
+	path string
+}
+
+// funcKey is the key of a function in funcDecls: the import path of its
+// package, the type of its receiver for a method, and its name
+func funcKey(pkg string, decl *ast.FuncDecl) string {
+	if decl.Recv != nil && len(decl.Recv.List) > 0 {
+		if recv, ok := receiverType(decl.Recv.List[0].Type); ok {
+			return methodKey(pkg, recv, decl.Name.Name)
+		}
+	}
+	return pkg + "." + decl.Name.Name
+}
+
+// methodKey is the key of the method name of the type recv in funcDecls
+func methodKey(pkg, recv, name string) string {
+	return pkg + "." + recv + "." + name
+}
+
+// receiverType returns the name of the type of a receiver, *Server and
+// Page[T] are Server and Page
+func receiverType(expr ast.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name, true
+	case *ast.StarExpr:
+		return receiverType(e.X)
+	case *ast.IndexExpr:
+		return receiverType(e.X)
+	case *ast.IndexListExpr:
+		return receiverType(e.X)
+	case *ast.ParenExpr:
+		return receiverType(e.X)
+	}
+	return "", false
+}
+
+// registerFuncs registers the functions of a file and the handlers documented
+// by the comment of a path
+func (spec *openAPI) registerFuncs(f *ast.File) map[*ast.CommentGroup]*ast.FuncDecl {
+	docs := make(map[*ast.CommentGroup]*ast.FuncDecl)
+	for _, decl := range f.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok || fd.Body == nil {
+			continue
+		}
+		spec.funcDecls[funcKey(spec.pkg(f), fd)] = funcDecl{file: f, decl: fd}
+		if fd.Doc != nil {
+			docs[fd.Doc] = fd
+		}
+	}
+	return docs
+}
+
+// writes are the status codes and the headers a function can write
+type writes struct {
+	codes   map[int]bool
+	headers map[string]bool
+	// statusParams are the indexes of the parameters written as status code
+	statusParams map[int]bool
+	// body tells if the function writes a body
+	body bool
+	// implicitOK tells if the function writes a body before any status code
+	// on one of its paths, the status code is then 200
+	implicitOK bool
+}
+
+func newWrites() *writes {
+	return &writes{
+		codes:        make(map[int]bool),
+		headers:      make(map[string]bool),
+		statusParams: make(map[int]bool),
+	}
+}
+
+// writesStatus tells if the function writes a status code, or its body and
+// then the status code 200
+func (w *writes) writesStatus() bool {
+	return len(w.codes) > 0 || len(w.statusParams) > 0 || w.body
+}
+
+// sortedCodes returns the status codes written, 200 when a body is written
+// before any status code
+func (w *writes) sortedCodes() []int {
+	codes := make([]int, 0, len(w.codes)+1)
+	for c := range w.codes {
+		codes = append(codes, c)
+	}
+	if w.implicitOK && !w.codes[http.StatusOK] {
+		codes = append(codes, http.StatusOK)
+	}
+	sort.Ints(codes)
+	return codes
+}
+
+func (w *writes) sortedHeaders() []string {
+	headers := make([]string, 0, len(w.headers))
+	for h := range w.headers {
+		headers = append(headers, h)
+	}
+	sort.Strings(headers)
+	return headers
+}
+
+// handlerAnalysis finds what the functions of the parsed packages write in
+// their response, the result of each function is computed once
+type handlerAnalysis struct {
+	// funcs returns the function of a package by key, see funcKey
+	funcs func(key string) (funcDecl, bool)
+	done  map[*ast.FuncDecl]*writes
+}
+
+// funcScope is what is known about the function being analysed
+type funcScope struct {
+	pkg     string
+	http    string
+	imports map[string]bool
+	// params are the indexes of the parameters by name
+	params map[string]int
+	// responseWriters are the names of the http.ResponseWriter parameters
+	responseWriters map[string]bool
+	// types are the types of the package of the receiver, the parameters and
+	// the variables by name, to find the methods they call
+	types map[string]string
+}
+
+func newFuncScope(pkg string, f *ast.File, decl *ast.FuncDecl) funcScope {
+	s := funcScope{
+		pkg:             pkg,
+		imports:         make(map[string]bool),
+		params:          make(map[string]int),
+		responseWriters: make(map[string]bool),
+		types:           make(map[string]string),
+	}
+	for name, p := range importNames(f) {
+		s.imports[name] = true
+		if p == "net/http" {
+			s.http = name
+		}
+	}
+
+	if decl.Recv != nil {
+		for _, fld := range decl.Recv.List {
+			s.declare(fld.Names, fld.Type, nil)
+		}
+	}
+	i := 0
+	for _, fld := range decl.Type.Params.List {
+		isWriter := s.isHTTP(fld.Type, "ResponseWriter")
+		s.declare(fld.Names, fld.Type, nil)
+		for _, n := range fld.Names {
+			s.params[n.Name] = i
+			if isWriter {
+				s.responseWriters[n.Name] = true
+			}
+			i++
+		}
+		if len(fld.Names) == 0 {
+			i++
+		}
+	}
+	return s
+}
+
+// declare records the types of the package of variables, given by their
+// type, i.e. var s *Server, or by their values, i.e. s := &Server{}
+func (s funcScope) declare(names []*ast.Ident, typ ast.Expr, values []ast.Expr) {
+	for i, n := range names {
+		t := typ
+		if t == nil && len(values) == len(names) {
+			t = valueType(values[i])
+		}
+		if name, ok := receiverType(t); ok {
+			s.types[n.Name] = name
+		} else {
+			delete(s.types, n.Name)
+		}
+	}
+}
+
+// valueType returns the type of the values Server{}, &Server{} and
+// new(Server)
+func valueType(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.CompositeLit:
+		return e.Type
+	case *ast.UnaryExpr:
+		if e.Op == token.AND {
+			return valueType(e.X)
+		}
+	case *ast.CallExpr:
+		if fn, ok := e.Fun.(*ast.Ident); ok && fn.Name == "new" && len(e.Args) == 1 {
+			return e.Args[0]
+		}
+	}
+	return nil
+}
+
+// isHTTP tells if expr is the identifier name of net/http
+func (s funcScope) isHTTP(expr ast.Expr, name string) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	x, ok := sel.X.(*ast.Ident)
+	return ok && s.http != "" && x.Name == s.http && sel.Sel.Name == name
+}
+
+// status returns the status code given by expr, a constant of net/http or a
+// number when literals is set
+func (s funcScope) status(expr ast.Expr, literals bool) (int, bool) {
+	switch e := expr.(type) {
+	case *ast.SelectorExpr:
+		if code, ok := statusCodes[e.Sel.Name]; ok && s.isHTTP(e, e.Sel.Name) {
+			return code, true
+		}
+	case *ast.BasicLit:
+		if code, err := strconv.Atoi(e.Value); literals && e.Kind == token.INT && err == nil && code >= 100 && code < 600 {
+			return code, true
+		}
+	}
+	return 0, false
+}
+
+// param returns the index of the parameter expr is
+func (s funcScope) param(expr ast.Expr) (int, bool) {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return 0, false
+	}
+	i, ok := s.params[ident.Name]
+	return i, ok
+}
+
+// helper returns the function of the package called by call, the methods are
+// found by the type of the variable they are called on
+func (a *handlerAnalysis) helper(s funcScope, call *ast.CallExpr) (funcDecl, bool) {
+	switch fn := call.Fun.(type) {
+	case *ast.Ident:
+		return a.funcs(s.pkg + "." + fn.Name)
+	case *ast.SelectorExpr:
+		x, ok := fn.X.(*ast.Ident)
+		if !ok {
+			return funcDecl{}, false
+		}
+		if recv, ok := s.types[x.Name]; ok {
+			return a.funcs(methodKey(s.pkg, recv, fn.Sel.Name))
+		}
+	}
+	return funcDecl{}, false
+}
+
+// writes returns what a function writes in its response, following the calls
+// to the functions of its package
+func (a *handlerAnalysis) writes(pkg string, fd funcDecl) *writes {
+	if w, ok := a.done[fd.decl]; ok {
+		return w
+	}
+	w := newWrites()
+	// a recursive call writes nothing more
+	a.done[fd.decl] = w
+
+	fl := handlerFlow{a: a, s: newFuncScope(pkg, fd.file, fd.decl), w: w}
+	fl.stmts(fd.decl.Body.List, false)
+	return w
+}
+
+// handlerFlow follows the statements of a function in their order, to know on which
+// paths the body is written before a status code
+type handlerFlow struct {
+	a *handlerAnalysis
+	s funcScope
+	w *writes
+}
+
+// pathEnd is the end of a path of a function: written tells if a status code
+// is written on the path, returns if the path returns
+type pathEnd struct {
+	written bool
+	returns bool
+}
+
+// joinPaths returns the end of the paths joined after a branch, a status code
+// is written when it's written on every path which doesn't return
+func joinPaths(ends []pathEnd) pathEnd {
+	joined := pathEnd{written: true, returns: true}
+	for _, e := range ends {
+		if !e.returns {
+			joined.returns = false
+			joined.written = joined.written && e.written
+		}
+	}
+	return joined
+}
+
+// stmts follows a list of statements, written tells if a status code is
+// written before them
+func (fl handlerFlow) stmts(list []ast.Stmt, written bool) pathEnd {
+	for _, st := range list {
+		end := fl.stmt(st, written)
+		if end.returns {
+			return end
+		}
+		written = end.written
+	}
+	return pathEnd{written: written}
+}
+
+func (fl handlerFlow) stmt(st ast.Stmt, written bool) pathEnd {
+	switch st := st.(type) {
+	case nil:
+		return pathEnd{written: written}
+
+	case *ast.BlockStmt:
+		return fl.stmts(st.List, written)
+
+	case *ast.LabeledStmt:
+		return fl.stmt(st.Stmt, written)
+
+	case *ast.ReturnStmt:
+		return pathEnd{written: fl.exprs(st, written), returns: true}
+
+	case *ast.IfStmt:
+		written = fl.stmt(st.Init, written).written
+		written = fl.exprs(st.Cond, written)
+		ends := []pathEnd{fl.stmts(st.Body.List, written), {written: written}}
+		if st.Else != nil {
+			ends[1] = fl.stmt(st.Else, written)
+		}
+		return joinPaths(ends)
+
+	case *ast.SwitchStmt:
+		written = fl.stmt(st.Init, written).written
+		written = fl.exprs(st.Tag, written)
+		return fl.clauses(st.Body, written)
+
+	case *ast.TypeSwitchStmt:
+		written = fl.stmt(st.Init, written).written
+		written = fl.stmt(st.Assign, written).written
+		return fl.clauses(st.Body, written)
+
+	case *ast.SelectStmt:
+		ends := []pathEnd{}
+		for _, c := range st.Body.List {
+			cc := c.(*ast.CommClause)
+			ends = append(ends, fl.stmts(cc.Body, fl.stmt(cc.Comm, written).written))
+		}
+		return joinPaths(ends)
+
+	case *ast.ForStmt:
+		written = fl.stmt(st.Init, written).written
+		written = fl.exprs(st.Cond, written)
+		// the loop may not run
+		fl.stmts(st.Body.List, written)
+		fl.stmt(st.Post, written)
+		return pathEnd{written: written}
+
+	case *ast.RangeStmt:
+		written = fl.exprs(st.X, written)
+		fl.stmts(st.Body.List, written)
+		return pathEnd{written: written}
+
+	case *ast.AssignStmt:
+		if st.Tok == token.DEFINE {
+			names := make([]*ast.Ident, 0, len(st.Lhs))
+			for _, lhs := range st.Lhs {
+				if n, ok := lhs.(*ast.Ident); ok {
+					names = append(names, n)
+				}
+			}
+			fl.s.declare(names, nil, st.Rhs)
+		}
+		return pathEnd{written: fl.exprs(st, written)}
+
+	case *ast.DeclStmt:
+		if gd, ok := st.Decl.(*ast.GenDecl); ok && gd.Tok == token.VAR {
+			for _, spc := range gd.Specs {
+				vs := spc.(*ast.ValueSpec)
+				fl.s.declare(vs.Names, vs.Type, vs.Values)
+			}
+		}
+		return pathEnd{written: fl.exprs(st, written)}
+
+	case *ast.ExprStmt:
+		written = fl.exprs(st, written)
+		if call, ok := st.X.(*ast.CallExpr); ok {
+			if fn, ok := call.Fun.(*ast.Ident); ok && fn.Name == "panic" {
+				return pathEnd{written: written, returns: true}
+			}
+		}
+		return pathEnd{written: written}
+	}
+	return pathEnd{written: fl.exprs(st, written)}
+}
+
+// clauses follows the clauses of a switch, none of them may run without a
+// default clause
+func (fl handlerFlow) clauses(body *ast.BlockStmt, written bool) pathEnd {
+	ends := []pathEnd{}
+	hasDefault := false
+	for _, c := range body.List {
+		cc := c.(*ast.CaseClause)
+		hasDefault = hasDefault || cc.List == nil
+		w := written
+		for _, e := range cc.List {
+			w = fl.exprs(e, w)
+		}
+		ends = append(ends, fl.stmts(cc.Body, w))
+	}
+	if !hasDefault {
+		ends = append(ends, pathEnd{written: written})
+	}
+	return joinPaths(ends)
+}
+
+// exprs follows the calls of a node, it returns if a status code is written
+// after them
+func (fl handlerFlow) exprs(n ast.Node, written bool) bool {
+	if n == nil || reflect.ValueOf(n).IsNil() {
+		return written
+	}
+	ast.Inspect(n, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		written = fl.call(call, written)
+		return true
+	})
+	return written
+}
+
+// call records what a call writes, it returns if a status code is written
+// after it
+func (fl handlerFlow) call(call *ast.CallExpr, written bool) bool {
+	s, w := fl.s, fl.w
+	// setStatus records the status code written by expr, or the parameter it
+	// comes from
+	setStatus := func(expr ast.Expr, literals bool) {
+		if code, ok := s.status(expr, literals); ok {
+			w.codes[code] = true
+		} else if i, ok := s.param(expr); ok {
+			w.statusParams[i] = true
+		}
+	}
+	// setBody records a body written, with the status code 200 when none is
+	// written before
+	setBody := func() {
+		w.body = true
+		if !written {
+			w.implicitOK = true
+		}
+	}
+	sel, _ := call.Fun.(*ast.SelectorExpr)
+
+	switch {
+	// w.WriteHeader(http.StatusCreated)
+	case sel != nil && sel.Sel.Name == "WriteHeader" && len(call.Args) == 1:
+		setStatus(call.Args[0], true)
+		return true
+
+	// w.Header().Set("Location", url)
+	case sel != nil && (sel.Sel.Name == "Set" || sel.Sel.Name == "Add") && len(call.Args) == 2 && isHeaderCall(sel.X):
+		if name, ok := stringLiteral(call.Args[0]); ok {
+			w.headers[http.CanonicalHeaderKey(name)] = true
+		}
+
+	// c.Header("Location", url) of web frameworks
+	case sel != nil && sel.Sel.Name == "Header" && len(call.Args) == 2:
+		if name, ok := stringLiteral(call.Args[0]); ok {
+			w.headers[http.CanonicalHeaderKey(name)] = true
+		}
+
+	// w.Write(data)
+	case sel != nil && sel.Sel.Name == "Write" && isIdent(sel.X, s.responseWriters):
+		setBody()
+		return true
+
+	case s.isHTTP(call.Fun, "NotFound"):
+		w.codes[http.StatusNotFound] = true
+		return true
+
+	case s.isHTTP(call.Fun, "Error") && len(call.Args) == 3:
+		setStatus(call.Args[2], true)
+		return true
+
+	case s.isHTTP(call.Fun, "Redirect") && len(call.Args) == 4:
+		setStatus(call.Args[3], true)
+		return true
+
+	default:
+		if fd, ok := fl.a.helper(s, call); ok {
+			hw := fl.a.writes(s.pkg, fd)
+			for c := range hw.codes {
+				w.codes[c] = true
+			}
+			for h := range hw.headers {
+				w.headers[h] = true
+			}
+			w.body = w.body || hw.body
+			if hw.implicitOK && !written {
+				w.implicitOK = true
+			}
+			for i := range hw.statusParams {
+				if i < len(call.Args) {
+					setStatus(call.Args[i], true)
+				}
+			}
+			return written || hw.writesStatus()
+		}
+
+		// json.NewEncoder(w), c.JSON(http.StatusOK, v)
+		status, body := false, false
+		for _, arg := range call.Args {
+			if isIdent(arg, s.responseWriters) {
+				body = true
+			}
+			if code, ok := s.status(arg, false); ok {
+				w.codes[code] = true
+				status = true
+			}
+		}
+		if body && !status {
+			setBody()
+		}
+		return written || status || body
+	}
+	return written
+}
+
+// isHeaderCall tells if expr is a call to the Header method
+func isHeaderCall(expr ast.Expr) bool {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) != 0 {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == "Header"
+}
+
+func isIdent(expr ast.Expr, names map[string]bool) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && names[ident.Name]
+}
+
+func stringLiteral(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+// documentsStatus tells if the responses document the status code, with the
+// code, a range of codes such as 4XX or the default response
+func documentsStatus(responses map[string]response, code int) bool {
+	for key := range responses {
+		k := strings.ToUpper(key)
+		if k == "DEFAULT" || k == strconv.Itoa(code) || k == fmt.Sprintf("%dXX", code/100) {
+			return true
+		}
+	}
+	return false
+}
+
+// analyseHandlers compares the responses of the operations documented on
+// functions with the status codes the functions write. The status codes are
+// the constants of net/http given to WriteHeader, http.Error, http.Redirect or
+// to the functions of the other packages, and the codes given to the helpers
+// of the package of the handler. It returns a warning for every status code
+// returned but not documented, and for every documented status code which is
+// never returned.
+func (spec *openAPI) analyseHandlers() (warnings []string) {
+	a := handlerAnalysis{funcs: spec.funcDecl, done: make(map[*ast.FuncDecl]*writes)}
+	sort.SliceStable(spec.handlers, func(i, j int) bool {
+		return spec.handlers[i].url < spec.handlers[j].url
+	})
+
+	for _, h := range spec.handlers {
+		op, ok := spec.Paths[h.url].Operations[h.verb]
+		if !ok {
+			continue
+		}
+		if h.decl == nil {
+			if h, ok = spec.handlerDecl(h); !ok {
+				continue
+			}
+		}
+		w := a.writes(h.pkg, funcDecl{file: h.file, decl: h.decl})
+		codes := w.sortedCodes()
+
+		logrus.
+			WithField("url", h.url).
+			WithField("verb", h.verb).
+			WithField("handler", h.decl.Name.Name).
+			WithField("codes", codes).
+			WithField("headers", w.sortedHeaders()).
+			Info("Analysing handler")
+
+		if len(codes) == 0 {
+			continue
+		}
+
+		warn := func(message string, code string) {
+			logrus.
+				WithField("url", h.url).
+				WithField("verb", h.verb).
+				WithField("handler", h.decl.Name.Name).
+				WithField("code", code).
+				Warn(message)
+			warnings = append(warnings, fmt.Sprintf("%s %s: %s %s", h.verb, h.url, strings.ToLower(message), code))
+		}
+
+		returned := make(map[string]bool, len(codes))
+		for _, c := range codes {
+			returned[strconv.Itoa(c)] = true
+			if !documentsStatus(op.Responses, c) {
+				warn("Handler returns an undocumented status code", strconv.Itoa(c))
+			}
+		}
+
+		documented := make([]string, 0, len(op.Responses))
+		for key := range op.Responses {
+			documented = append(documented, key)
+		}
+		sort.Strings(documented)
+		for _, key := range documented {
+			if _, err := strconv.Atoi(key); err != nil || returned[key] {
+				continue
+			}
+			// the default responses may be returned by a middleware
+			if _, ok := spec.defaultResponses[key]; ok {
+				continue
+			}
+			// the body may be written without a status code, with 200
+			if key == "200" && w.body {
+				continue
+			}
+			warn("Handler never returns the documented status code", key)
+		}
+	}
+	return warnings
+}
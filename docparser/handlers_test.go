@@ -0,0 +1,169 @@
+package docparser
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyseHandlers(t *testing.T) {
+	f, err := parseFile("datatest/handlers.go")
+	assert.NoError(t, err)
+
+	spec := NewOpenAPI()
+	assert.Empty(t, spec.parsePaths(f))
+
+	assert.Equal(t, []string{
+		"post /pets: handler returns an undocumented status code 500",
+		"post /pets: handler never returns the documented status code 409",
+		"get /pets/{id}: handler never returns the documented status code 404",
+	}, spec.analyseHandlers())
+}
+
+func TestHandlerWrites(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		codes   []int
+		headers []string
+	}{
+		{
+			name:  "write header",
+			body:  "w.WriteHeader(http.StatusAccepted)",
+			codes: []int{202},
+		},
+		{
+			name:  "literal",
+			body:  "w.WriteHeader(204)",
+			codes: []int{204},
+		},
+		{
+			name:  "body only",
+			body:  `w.Write([]byte("ok"))`,
+			codes: []int{200},
+		},
+		{
+			name:  "not found and redirect",
+			body:  `http.NotFound(w, r); http.Redirect(w, r, "/", http.StatusFound)`,
+			codes: []int{302, 404},
+		},
+		{
+			name:  "framework",
+			body:  `c.JSON(http.StatusOK, nil); c.Header("x-request-id", "1")`,
+			codes: []int{200}, headers: []string{"X-Request-Id"},
+		},
+		{
+			name:  "helper",
+			body:  "respond(w, http.StatusConflict)",
+			codes: []int{409}, headers: []string{"Content-Type"},
+		},
+		{
+			name:  "method helper",
+			body:  "s := &server{}; s.fail(w)",
+			codes: []int{503},
+		},
+		{
+			name:  "method of the type of the variable",
+			body:  "var c client; c.fail(w)",
+			codes: []int{502},
+		},
+		{
+			name:  "status code written on a branch",
+			body:  "if r == nil { w.WriteHeader(http.StatusNotFound); return }; json.NewEncoder(w).Encode(nil)",
+			codes: []int{200, 404},
+		},
+		{
+			name:  "status code written on a branch which doesn't return",
+			body:  "if r == nil { w.WriteHeader(http.StatusNotFound) }; w.Write(nil)",
+			codes: []int{200, 404},
+		},
+		{
+			name:  "body after the status code",
+			body:  "if r == nil { w.WriteHeader(http.StatusNotFound) } else { w.WriteHeader(http.StatusCreated) }; w.Write(nil)",
+			codes: []int{201, 404},
+		},
+		{
+			name:  "body after the status code of a helper",
+			body:  "respond(w, http.StatusConflict); json.NewEncoder(w).Encode(nil)",
+			codes: []int{409}, headers: []string{"Content-Type"},
+		},
+		{
+			name: "not a status code",
+			body: "limit(w, 250)",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			src := `package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+func Handler(w http.ResponseWriter, r *http.Request) {
+	` + tc.body + `
+}
+
+func respond(w http.ResponseWriter, code int) {
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(code)
+}
+
+func limit(w http.ResponseWriter, n int) {}
+
+func (s *server) fail(w http.ResponseWriter) {
+	respond503(w)
+}
+
+func (c client) fail(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusBadGateway)
+}
+
+func respond503(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusServiceUnavailable)
+}
+`
+			f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+			assert.NoError(t, err)
+
+			spec := NewOpenAPI()
+			spec.registerFuncs(f)
+			a := handlerAnalysis{funcs: spec.funcDecl, done: make(map[*ast.FuncDecl]*writes)}
+			w := a.writes("handlers", spec.funcDecls["handlers.Handler"])
+
+			assert.ElementsMatch(t, tc.codes, w.sortedCodes())
+			assert.ElementsMatch(t, tc.headers, w.sortedHeaders())
+		})
+	}
+}
+
+func TestHandlerPackages(t *testing.T) {
+	spec := NewOpenAPI()
+	for pkg, code := range map[string]string{"example.com/a/api": "http.StatusConflict", "example.com/b/api": "http.StatusGone"} {
+		src := `package api
+
+import "net/http"
+
+func Handler(w http.ResponseWriter, r *http.Request) {
+	respond(w)
+}
+
+func respond(w http.ResponseWriter) {
+	w.WriteHeader(` + code + `)
+}
+`
+		f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+		assert.NoError(t, err)
+		spec.pkgPath = pkg
+		spec.registerFuncs(f)
+	}
+
+	a := handlerAnalysis{funcs: spec.funcDecl, done: make(map[*ast.FuncDecl]*writes)}
+	assert.Equal(t, []int{409}, a.writes("example.com/a/api", spec.funcDecls["example.com/a/api.Handler"]).sortedCodes())
+	assert.Equal(t, []int{410}, a.writes("example.com/b/api", spec.funcDecls["example.com/b/api.Handler"]).sortedCodes())
+}
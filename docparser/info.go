@@ -0,0 +1,261 @@
+package docparser
+
+import (
+	"fmt"
+	"go/ast"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+type info struct {
+	Title          string     `yaml:"title"`
+	Summary        string     `yaml:"summary,omitempty"`
+	Description    string     `yaml:"description,omitempty"`
+	TermsOfService string     `yaml:"termsOfService,omitempty"`
+	Contact        *contact   `yaml:"contact,omitempty"`
+	License        *license   `yaml:"license,omitempty"`
+	Version        string     `yaml:"version"`
+	Extensions     extensions `yaml:",inline"`
+}
+
+type contact struct {
+	Name       string     `yaml:"name,omitempty"`
+	URL        string     `yaml:"url,omitempty"`
+	Email      string     `yaml:"email,omitempty"`
+	Extensions extensions `yaml:",inline"`
+}
+
+type license struct {
+	Name       string     `yaml:"name"`
+	Identifier string     `yaml:"identifier,omitempty"`
+	URL        string     `yaml:"url,omitempty"`
+	Extensions extensions `yaml:",inline"`
+}
+
+func (spec *openAPI) parseInfos(f *ast.File) (errors []error) {
+	for _, s := range f.Comments {
+		t := s.Text()
+		// Test if comment is an info block
+		a := regexpInfo.FindSubmatch([]byte(t))
+		if len(a) == 0 {
+			continue
+		}
+
+		// The info block ends with the next annotation, emails may contain @
+		block := string(a[1])
+		if i := strings.Index(block, "@openapi:"); i >= 0 {
+			block = block[:i]
+		}
+
+		// Replacing tab with spaces
+		content := tab.ReplaceAllString(block, "  ")
+
+		// Unmarshal yaml
+		infos := info{}
+		err := yaml.Unmarshal([]byte(content), &infos)
+		if err != nil {
+			logrus.
+				WithError(err).
+				WithField("content", content).
+				Error("Unable to unmarshal infos")
+			errors = append(errors, &BuildError{
+				Err:     err,
+				Content: content,
+				Message: "Unable to unmarshal infos",
+			})
+			continue
+		}
+
+		if p, err := parseImportContentPath(infos.Description); err == nil {
+			c, err := ioutil.ReadFile(p)
+			if err != nil {
+				logrus.
+					WithField("File", p).
+					WithError(err).
+					Error("Could not import file")
+				errors = append(errors, &BuildError{
+					Err:     err,
+					Content: p,
+					Message: "Could not import file",
+				})
+				continue
+			}
+			infos.Description = string(c)
+		}
+
+		logrus.
+			WithField("title", infos.Title).
+			WithField("version", infos.Version).
+			Info("Parsing info")
+		spec.warnings = append(spec.warnings, spec.Info.merge(infos)...)
+	}
+	return
+}
+
+// merge sets the fields of the info which are still empty, a field already
+// set to a different value is kept and the conflict is returned as a warning
+func (i *info) merge(other info) (warnings []string) {
+	set := func(field string, current *string, value string) {
+		switch {
+		case value == "" || *current == value:
+		case *current == "":
+			*current = value
+		default:
+			warnings = append(warnings, infoConflict(field, *current, value))
+		}
+	}
+
+	set("title", &i.Title, other.Title)
+	set("summary", &i.Summary, other.Summary)
+	set("description", &i.Description, other.Description)
+	set("termsOfService", &i.TermsOfService, other.TermsOfService)
+	set("version", &i.Version, other.Version)
+
+	if other.Contact != nil {
+		if i.Contact == nil {
+			i.Contact = &contact{}
+		}
+		set("contact.name", &i.Contact.Name, other.Contact.Name)
+		set("contact.url", &i.Contact.URL, other.Contact.URL)
+		set("contact.email", &i.Contact.Email, other.Contact.Email)
+		warnings = append(warnings, mergeInfoExtensions("contact.", &i.Contact.Extensions, other.Contact.Extensions)...)
+	}
+
+	if other.License != nil {
+		if i.License == nil {
+			i.License = &license{}
+		}
+		set("license.name", &i.License.Name, other.License.Name)
+		// the identifier and the url of a license are mutually exclusive,
+		// the first one set is kept
+		if other.License.Identifier != "" && i.License.URL != "" {
+			warnings = append(warnings, exclusiveConflict("license.identifier", "license.url", other.License.Identifier))
+		} else {
+			set("license.identifier", &i.License.Identifier, other.License.Identifier)
+		}
+		if other.License.URL != "" && i.License.Identifier != "" {
+			warnings = append(warnings, exclusiveConflict("license.url", "license.identifier", other.License.URL))
+		} else {
+			set("license.url", &i.License.URL, other.License.URL)
+		}
+		warnings = append(warnings, mergeInfoExtensions("license.", &i.License.Extensions, other.License.Extensions)...)
+	}
+
+	warnings = append(warnings, mergeInfoExtensions("", &i.Extensions, other.Extensions)...)
+	return
+}
+
+// mergeInfoExtensions adds the x- extensions of other which are missing from
+// current, prefix is the path of their object in the info
+func mergeInfoExtensions(prefix string, current *extensions, other extensions) (warnings []string) {
+	keys := make([]string, 0, len(other))
+	for k := range other {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := other[k]
+		if !strings.HasPrefix(k, "x-") {
+			logrus.
+				WithField("field", prefix+k).
+				Warn("Unknown info field, only x- extensions are allowed")
+			continue
+		}
+		value, ok := (*current)[k]
+		switch {
+		case !ok:
+			if *current == nil {
+				*current = make(extensions)
+			}
+			(*current)[k] = v
+		case !reflect.DeepEqual(value, v):
+			warnings = append(warnings, infoConflict(prefix+k, value, v))
+		}
+	}
+	return
+}
+
+// override sets the fields of the info which are set in other
+func (i *info) override(other info) {
+	set := func(current *string, value string) {
+		if value != "" {
+			*current = value
+		}
+	}
+
+	set(&i.Title, other.Title)
+	set(&i.Summary, other.Summary)
+	set(&i.Description, other.Description)
+	set(&i.TermsOfService, other.TermsOfService)
+	set(&i.Version, other.Version)
+
+	if other.Contact != nil {
+		if i.Contact == nil {
+			i.Contact = &contact{}
+		}
+		set(&i.Contact.Name, other.Contact.Name)
+		set(&i.Contact.URL, other.Contact.URL)
+		set(&i.Contact.Email, other.Contact.Email)
+		overrideExtensions(&i.Contact.Extensions, other.Contact.Extensions)
+	}
+
+	if other.License != nil {
+		if i.License == nil {
+			i.License = &license{}
+		}
+		set(&i.License.Name, other.License.Name)
+		// the identifier and the url of a license are mutually exclusive
+		if other.License.Identifier != "" {
+			i.License.Identifier = other.License.Identifier
+			i.License.URL = ""
+		}
+		if other.License.URL != "" {
+			i.License.URL = other.License.URL
+			i.License.Identifier = ""
+		}
+		overrideExtensions(&i.License.Extensions, other.License.Extensions)
+	}
+
+	overrideExtensions(&i.Extensions, other.Extensions)
+}
+
+// overrideExtensions sets the extensions of other in current
+func overrideExtensions(current *extensions, other extensions) {
+	for k, v := range other {
+		if *current == nil {
+			*current = make(extensions)
+		}
+		(*current)[k] = v
+	}
+}
+
+// usesOpenAPI31 tells if the info has fields which only exist in OpenAPI 3.1,
+// the summary and the identifier of the license
+func (i info) usesOpenAPI31() bool {
+	return i.Summary != "" || (i.License != nil && i.License.Identifier != "")
+}
+
+// infoConflict returns the warning of the value of field which is ignored
+// because the field is already set to current
+func infoConflict(field string, current, value interface{}) string {
+	logrus.
+		WithField(field, current).
+		WithField(field+"_scanned", value).
+		Warn("Info field already exists and is different!")
+	return fmt.Sprintf("info %s is already set to %v, %v is ignored", field, current, value)
+}
+
+// exclusiveConflict returns the warning of the value of field which is ignored
+// because the field other, which excludes it, is already set
+func exclusiveConflict(field, other string, value interface{}) string {
+	logrus.
+		WithField(field, value).
+		WithField("exclusive", other).
+		Warn("Info field excludes a field already set!")
+	return fmt.Sprintf("info %s is already set, %s %v is ignored", other, field, value)
+}
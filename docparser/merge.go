@@ -0,0 +1,208 @@
+package docparser
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Merge adds the paths, the webhooks, the schemas, the reusable components,
+// the servers, the tags, the security requirements, the external docs and the
+// extensions of other to the document. The operations of other replace the
+// ones of the document, it fails when a schema, a component, a tag, the
+// external docs or an extension already exists and is different. The document
+// becomes an OpenAPI 3.1 document when it gets webhooks.
+func (spec *openAPI) Merge(other openAPI) error {
+	if spec.Paths == nil {
+		spec.Paths = make(paths)
+	}
+	spec.Paths.merge(other.Paths)
+	if len(other.Webhooks) > 0 {
+		if spec.Webhooks == nil {
+			spec.Webhooks = make(paths)
+		}
+		spec.Webhooks.merge(other.Webhooks)
+	}
+
+	if spec.Components.Schemas == nil {
+		spec.Components.Schemas = make(map[string]interface{})
+	}
+	for k, v := range other.Components.Schemas {
+		if s, ok := spec.Components.Schemas[k]; ok {
+			if !reflect.DeepEqual(s, v) {
+				return fmt.Errorf("schema %s already exists and is different", k)
+			}
+			continue
+		}
+		spec.Components.Schemas[k] = v
+		logrus.WithField("schema", k).Info("Adding Schema")
+	}
+
+	if err := spec.Components.MergeComponents(other.Components); err != nil {
+		return err
+	}
+
+	registeredServers := make(map[string]bool)
+	for _, server := range spec.Servers {
+		registeredServers[server.URL] = true
+	}
+	for _, server := range other.Servers {
+		if registeredServers[server.URL] {
+			continue
+		}
+		spec.Servers = append(spec.Servers, server)
+		registeredServers[server.URL] = true
+	}
+
+	for _, tg := range other.Tags {
+		if err := spec.addTag(tg); err != nil {
+			return fmt.Errorf("tag %s: %w", tg.Name, err)
+		}
+	}
+	for _, r := range other.Security {
+		spec.addSecurity(r)
+	}
+	if other.ExternalDocs != nil {
+		if spec.ExternalDocs != nil && !reflect.DeepEqual(spec.ExternalDocs, other.ExternalDocs) {
+			return errors.New("external docs already exist and are different")
+		}
+		spec.ExternalDocs = other.ExternalDocs
+	}
+
+	// the tag groups of a document read from a file are maps
+	groups, err := tagGroups(append(append([]interface{}{}, spec.XGroupTags...), other.XGroupTags...))
+	if err != nil {
+		return err
+	}
+	spec.XGroupTags = nil
+	for _, g := range groups {
+		if err := spec.addTagGroup(g); err != nil {
+			return fmt.Errorf("tag group %s: %w", g.Name, err)
+		}
+	}
+
+	for _, name := range sortedKeys(reflect.ValueOf(other.Extensions)) {
+		if err := spec.addExtension(name, other.Extensions[name]); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	spec.upgradeVersion()
+	return nil
+}
+
+// merge adds the path items of other, their operations replace the existing
+// ones
+func (ps paths) merge(other paths) {
+	for url, p := range other {
+		if p.isRaw {
+			if _, ok := ps[url]; !ok {
+				ps[url] = p
+			}
+			continue
+		}
+
+		current := ps[url]
+		current.mergeFields(p)
+		for verb, op := range p.Operations {
+			logrus.WithField("verb", verb).WithField("url", url).Info("Adding Path")
+			current.Operations[verb] = op
+		}
+		ps[url] = current
+	}
+}
+
+// mergeFile adds to the document what is parsed in a file, the conflicts are
+// reported like when the file is parsed into the document
+func (spec *openAPI) mergeFile(file *openAPI) (errs []error) {
+	fail := func(err error, content, message string) {
+		logrus.
+			WithError(err).
+			WithField("content", content).
+			Error(message)
+		errs = append(errs, &BuildError{
+			Err:     err,
+			Content: content,
+			Message: message,
+		})
+	}
+
+	spec.warnings = append(spec.warnings, file.warnings...)
+	spec.warnings = append(spec.warnings, spec.Info.merge(file.Info)...)
+
+	for _, name := range sortedKeys(reflect.ValueOf(file.registeredSchemas)) {
+		entity := file.registeredSchemas[name]
+		if current, ok := spec.registeredSchemas[name]; ok && !reflect.DeepEqual(current, entity) {
+			fail(fmt.Errorf("schema %s is declared twice", name), name, "schema already exists and is different")
+			continue
+		}
+		spec.registeredSchemas[name] = entity
+	}
+	for _, key := range sortedKeys(reflect.ValueOf(file.typeDecls)) {
+		if _, ok := spec.typeDecls[key]; ok {
+			fail(fmt.Errorf("type %s is declared twice", key), key, "type already exists")
+			continue
+		}
+		spec.typeDecls[key] = file.typeDecls[key]
+	}
+	for key, decl := range file.funcDecls {
+		spec.funcDecls[key] = decl
+	}
+	spec.handlers = append(spec.handlers, file.handlers...)
+
+	for _, kind := range componentKinds {
+		m := reflect.ValueOf(file.Components.components(kind))
+		for _, name := range sortedKeys(m) {
+			if err := addComponent(spec.Components.components(kind), name, m.MapIndex(reflect.ValueOf(name)).Interface()); err != nil {
+				fail(err, fmt.Sprintf("%s: %s", kind, name), "component already exists and is different")
+			}
+		}
+	}
+
+	for _, r := range file.Security {
+		spec.addSecurity(r)
+	}
+	for _, srv := range file.Servers {
+		if err := spec.addServer(srv); err != nil {
+			fail(err, srv.URL, "server already exists and is different")
+		}
+	}
+	for _, tg := range file.Tags {
+		if err := spec.addTag(tg); err != nil {
+			fail(err, tg.Name, "tag already exists and is different")
+		}
+	}
+	for _, group := range file.XGroupTags {
+		g := group.(tagGroup)
+		if err := spec.addTagGroup(g); err != nil {
+			fail(err, g.Name, "tag group already exists and is different")
+		}
+	}
+
+	ext := reflect.ValueOf(file.Extensions)
+	for _, name := range sortedKeys(ext) {
+		if err := spec.addExtension(name, file.Extensions[name]); err != nil {
+			fail(err, name, "extension already exists and is different")
+		}
+	}
+
+	for _, url := range sortedKeys(reflect.ValueOf(file.Paths)) {
+		errs = append(errs, spec.addPath(url, file.Paths[url])...)
+	}
+	for _, name := range sortedKeys(reflect.ValueOf(file.Webhooks)) {
+		errs = append(errs, spec.addWebhook(name, file.Webhooks[name])...)
+	}
+	return errs
+}
+
+// sortedKeys returns the sorted keys of a map with string keys
+func sortedKeys(m reflect.Value) []string {
+	keys := make([]string, 0, m.Len())
+	for _, k := range m.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,153 @@
+package docparser
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const mergeDocument = `openapi: 3.0.0
+info:
+  title: Pets
+  version: 1.0.0
+paths: {}
+tags:
+- name: pet
+  description: Pets
+security:
+- api_key: []
+externalDocs:
+  url: https://example.com/docs
+x-tagGroups:
+- name: Animals
+  tags:
+  - pet
+x-logo:
+  url: https://example.com/logo.png
+`
+
+func TestMerge(t *testing.T) {
+	spec := NewOpenAPI()
+	assert.NoError(t, yaml.Unmarshal([]byte(mergeDocument), &spec))
+
+	other := NewOpenAPI()
+	assert.NoError(t, yaml.Unmarshal([]byte(`openapi: 3.0.0
+info:
+  title: Stores
+  version: 1.0.0
+paths: {}
+tags:
+- name: pet
+  description: Pets
+- name: store
+security:
+- oauth: [read]
+externalDocs:
+  url: https://example.com/docs
+x-tagGroups:
+- name: Animals
+  tags:
+  - pet
+- name: Shops
+  tags:
+  - store
+x-audience: public
+`), &other))
+	assert.NoError(t, spec.Merge(other))
+
+	b, err := yaml.Marshal(&spec)
+	assert.NoError(t, err)
+	assert.Equal(t, `openapi: 3.0.0
+info:
+  title: Pets
+  version: 1.0.0
+paths: {}
+tags:
+- name: pet
+  description: Pets
+- name: store
+security:
+- api_key: []
+- oauth:
+  - read
+externalDocs:
+  url: https://example.com/docs
+x-tagGroups:
+- name: Animals
+  tags:
+  - pet
+- name: Shops
+  tags:
+  - store
+x-audience: public
+x-logo:
+  url: https://example.com/logo.png
+`, string(b))
+}
+
+func TestMergeConflicts(t *testing.T) {
+	tests := []struct {
+		name  string
+		other string
+	}{
+		{name: "tag", other: "tags:\n- name: pet\n  description: Animals\n"},
+		{name: "external docs", other: "externalDocs:\n  url: https://example.com/other\n"},
+		{name: "tag group", other: "x-tagGroups:\n- name: Animals\n  tags:\n  - cat\n"},
+		{name: "extension", other: "x-logo:\n  url: https://example.com/other.png\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := NewOpenAPI()
+			assert.NoError(t, yaml.Unmarshal([]byte(mergeDocument), &spec))
+
+			other := NewOpenAPI()
+			assert.NoError(t, yaml.Unmarshal([]byte(tt.other), &other))
+			assert.Error(t, spec.Merge(other))
+		})
+	}
+}
+
+func TestMergeFileConflicts(t *testing.T) {
+	files := []string{`package a
+
+// @openapi:schema
+type Pet struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+`, `package b
+
+// @openapi:schema
+type Pet struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+`, `package a
+
+// @openapi:schema
+type Pet struct {
+	Legs int ` + "`json:\"legs\"`" + `
+}
+`}
+
+	spec := NewOpenAPI()
+	var errs []error
+	for _, src := range files {
+		f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+		assert.NoError(t, err)
+		file := spec.fileSpec()
+		assert.Empty(t, file.parseSchemas(f))
+		errs = append(errs, spec.mergeFile(file)...)
+	}
+
+	// the same schema declared in two packages isn't a conflict
+	assert.Contains(t, spec.typeDecls, "a.Pet")
+	assert.Contains(t, spec.typeDecls, "b.Pet")
+	if assert.Len(t, errs, 2) {
+		assert.Equal(t, "schema already exists and is different", errs[0].(*BuildError).Message)
+		assert.Equal(t, "type already exists", errs[1].(*BuildError).Message)
+	}
+	assert.Contains(t, spec.registeredSchemas["Pet"].(*schema).Properties, "name")
+}
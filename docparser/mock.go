@@ -0,0 +1,540 @@
+package docparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	regexpPathParam = regexp.MustCompile(`\{([^}]+)\}`)
+	regexpPreferred = regexp.MustCompile(`(?:^|[,;\s])code=(\d{3})\b`)
+)
+
+// mock responds to the operations of the document with their examples
+type mock struct {
+	routes     []mockRoute
+	basePaths  []string
+	components Components
+	sampler    sampler
+	validator  validator
+}
+
+// mockRoute is an operation, its url template is matched by pattern
+type mockRoute struct {
+	url     string
+	verb    string
+	pattern *regexp.Regexp
+	params  []string
+	path    path
+	op      operation
+}
+
+// Mock returns a handler serving every operation of the document. The
+// requests are validated against the parameters and the request bodies, and
+// the operations respond with their examples, or with values synthesised from
+// their schemas. The response is chosen with the header Prefer: code=404.
+func (spec *openAPI) Mock() (http.Handler, error) {
+	schemas, err := spec.componentSchemas()
+	if err != nil {
+		return nil, err
+	}
+	m := &mock{
+		components: spec.Components,
+		sampler:    sampler{schemas: schemas},
+		validator:  validator{schemas: schemas},
+	}
+
+	for _, url := range sortedKeys(reflect.ValueOf(spec.Paths)) {
+		p := spec.Paths[url]
+		if p.isRaw {
+			continue
+		}
+		pattern, params := pathPattern(url)
+		for _, verb := range verbs {
+			if op, ok := p.Operations[verb]; ok {
+				m.routes = append(m.routes, mockRoute{url: url, verb: verb, pattern: pattern, params: params, path: p, op: op})
+			}
+		}
+	}
+	// the concrete paths are matched before the templated ones, i.e.
+	// /pets/mine before /pets/{id}
+	sort.SliceStable(m.routes, func(i, j int) bool {
+		return len(m.routes[i].params) < len(m.routes[j].params)
+	})
+
+	for _, s := range spec.Servers {
+		if u, err := url.Parse(s.URL); err == nil && u.Path != "" && u.Path != "/" && !strings.Contains(u.Path, "{") {
+			m.basePaths = append(m.basePaths, strings.TrimSuffix(u.Path, "/"))
+		}
+	}
+	return m, nil
+}
+
+// pathPattern returns the expression matching the url template and the names
+// of its parameters
+func pathPattern(url string) (*regexp.Regexp, []string) {
+	var params []string
+	pattern := "^"
+	last := 0
+	for _, loc := range regexpPathParam.FindAllStringSubmatchIndex(url, -1) {
+		pattern += regexp.QuoteMeta(url[last:loc[0]]) + "([^/]+)"
+		params = append(params, url[loc[2]:loc[3]])
+		last = loc[1]
+	}
+	pattern += regexp.QuoteMeta(url[last:]) + "/?$"
+	return regexp.MustCompile(pattern), params
+}
+
+func (m *mock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// the mock is called by the frontends served from other origins
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	route, values, allowed := m.match(r.Method, r.URL.EscapedPath())
+	if route == nil {
+		if len(allowed) == 0 {
+			writeMockError(w, http.StatusNotFound, fmt.Sprintf("No operation matches %s %s", r.Method, r.URL.Path), nil)
+			return
+		}
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(allowed, ", "))
+			w.Header().Set("Access-Control-Allow-Headers", "*")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeMockError(w, http.StatusMethodNotAllowed, fmt.Sprintf("The operations of %s are %s", r.URL.Path, strings.Join(allowed, ", ")), nil)
+		return
+	}
+
+	status, errs := m.validateRequest(r, route, values)
+	if len(errs) > 0 {
+		writeMockError(w, status, fmt.Sprintf("The request doesn't match the operation %s %s", strings.ToUpper(route.verb), route.url), errs)
+		return
+	}
+	m.respond(w, r, route)
+}
+
+// match returns the route of the request and the values of its path
+// parameters, or the methods allowed on the path when the verb doesn't match
+func (m *mock) match(method, escapedPath string) (*mockRoute, map[string]string, []string) {
+	candidates := []string{escapedPath}
+	for _, base := range m.basePaths {
+		if strings.HasPrefix(escapedPath, base+"/") {
+			candidates = append(candidates, strings.TrimPrefix(escapedPath, base))
+		}
+	}
+
+	var allowed []string
+	for _, p := range candidates {
+		for i := range m.routes {
+			route := &m.routes[i]
+			matches := route.pattern.FindStringSubmatch(p)
+			if matches == nil {
+				continue
+			}
+			if !strings.EqualFold(route.verb, method) {
+				if verb := strings.ToUpper(route.verb); !contains(allowed, verb) {
+					allowed = append(allowed, verb)
+				}
+				continue
+			}
+			values := make(map[string]string, len(route.params))
+			for j, name := range route.params {
+				v, err := url.PathUnescape(matches[j+1])
+				if err != nil {
+					v = matches[j+1]
+				}
+				values[name] = v
+			}
+			return route, values, nil
+		}
+	}
+	return nil, nil, allowed
+}
+
+// validateRequest returns the status and the reasons why the request doesn't
+// match its operation
+func (m *mock) validateRequest(r *http.Request, route *mockRoute, pathValues map[string]string) (int, []string) {
+	var errs []string
+	for _, prm := range m.parameters(route) {
+		// a path parameter missing from the template can't be given
+		if prm.Schema == nil && prm.In != "path" || prm.In == "path" && !contains(route.params, prm.Name) {
+			continue
+		}
+		raw, ok := parameterValues(r, prm, pathValues)
+		if !ok {
+			if prm.Required || prm.In == "path" {
+				errs = append(errs, fmt.Sprintf("the %s parameter %s is required", prm.In, prm.Name))
+			}
+			continue
+		}
+		value, err := m.coerce(prm.Schema, raw)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("the %s parameter %s %v", prm.In, prm.Name, err))
+			continue
+		}
+		errs = append(errs, m.validator.validate(prm.Schema, value, prm.Name)...)
+	}
+
+	body := route.op.RequestBody
+	if resolved, ok := m.components.RequestBodies[refName(body.Ref)]; ok && body.Ref != "" {
+		body = resolved
+	}
+	if len(body.Content) == 0 {
+		return http.StatusBadRequest, errs
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return http.StatusBadRequest, append(errs, err.Error())
+	}
+	if len(data) == 0 {
+		if body.Required {
+			errs = append(errs, "the request body is required")
+		}
+		return http.StatusBadRequest, errs
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "application/octet-stream"
+	}
+	media, ok := body.Content[matchMediaType(mediaType, body.Content)]
+	if !ok {
+		return http.StatusUnsupportedMediaType, append(errs, fmt.Sprintf("the content type %s isn't accepted", mediaType))
+	}
+	switch {
+	case isJSON(mediaType):
+		var value interface{}
+		if err := json.Unmarshal(data, &value); err != nil {
+			return http.StatusBadRequest, append(errs, fmt.Sprintf("the request body isn't valid json: %v", err))
+		}
+		errs = append(errs, m.validator.validate(media.Schema, value, "body")...)
+	case mediaType == "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(data))
+		if err != nil {
+			return http.StatusBadRequest, append(errs, err.Error())
+		}
+		value, ferrs := m.formValue(media.Schema, form)
+		errs = append(append(errs, ferrs...), m.validator.validate(media.Schema, value, "body")...)
+	}
+	return http.StatusBadRequest, errs
+}
+
+// parameters returns the parameters of the operation and of its path item,
+// the references resolved
+func (m *mock) parameters(route *mockRoute) []parameter {
+	var list []parameter
+	index := make(map[string]int)
+	for _, prm := range append(append([]parameter{}, route.path.Parameters...), route.op.Parameters...) {
+		if prm.Ref != "" {
+			resolved, ok := m.components.Parameters[refName(prm.Ref)]
+			if !ok {
+				continue
+			}
+			prm = resolved
+		}
+		// the parameters of the operation override the ones of the path
+		key := prm.In + ":" + prm.Name
+		if i, ok := index[key]; ok {
+			list[i] = prm
+			continue
+		}
+		index[key] = len(list)
+		list = append(list, prm)
+	}
+	return list
+}
+
+// parameterValues returns the raw values of a parameter in the request
+func parameterValues(r *http.Request, prm parameter, pathValues map[string]string) ([]string, bool) {
+	switch prm.In {
+	case "path":
+		v, ok := pathValues[prm.Name]
+		return []string{v}, ok
+	case "query":
+		v, ok := r.URL.Query()[prm.Name]
+		return v, ok
+	case "header":
+		v, ok := r.Header[http.CanonicalHeaderKey(prm.Name)]
+		return v, ok
+	case "cookie":
+		c, err := r.Cookie(prm.Name)
+		if err != nil {
+			return nil, false
+		}
+		return []string{c.Value}, true
+	}
+	return nil, false
+}
+
+// coerce converts the raw values of a parameter or a form field to the type
+// of its schema, the items of an array are repeated or separated by commas
+func (m *mock) coerce(s *schema, raw []string) (interface{}, error) {
+	s = resolveSchema(m.validator.schemas, s)
+	if s != nil && s.Type == "array" {
+		items := []interface{}{}
+		for _, r := range raw {
+			for _, part := range strings.Split(r, ",") {
+				v, err := m.coerce(s.Items, []string{part})
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, v)
+			}
+		}
+		return items, nil
+	}
+
+	v := ""
+	if len(raw) > 0 {
+		v = raw[0]
+	}
+	if s == nil {
+		return v, nil
+	}
+	switch s.Type {
+	case "integer", "number":
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be %s %s, got %q", article(s.Type), s.Type, v)
+		}
+		return f, nil
+	case "boolean":
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("must be a boolean, got %q", v)
+		}
+		return b, nil
+	}
+	return v, nil
+}
+
+// formValue returns the fields of a form as an object of the schema
+func (m *mock) formValue(s *schema, form url.Values) (map[string]interface{}, []string) {
+	var errs []string
+	s = resolveSchema(m.validator.schemas, s)
+	value := make(map[string]interface{}, len(form))
+	for name, raw := range form {
+		var p *schema
+		if s != nil {
+			p = s.Properties[name]
+		}
+		v, err := m.coerce(p, raw)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("body.%s %v", name, err))
+			continue
+		}
+		value[name] = v
+	}
+	return value, errs
+}
+
+// respond writes the response chosen by the header Prefer, or the first
+// success response of the operation
+func (m *mock) respond(w http.ResponseWriter, r *http.Request, route *mockRoute) {
+	code, status, ok := chooseResponse(route.op.Responses, preferredCode(r))
+	if !ok {
+		writeMockError(w, http.StatusInternalServerError, fmt.Sprintf("The operation %s %s doesn't document the response %s", strings.ToUpper(route.verb), route.url, preferredCode(r)), nil)
+		return
+	}
+	resp := route.op.Responses[code]
+	if resolved, ok := m.components.Responses[refName(resp.Ref)]; ok && resp.Ref != "" {
+		resp = resolved
+	}
+
+	for name, h := range resp.Headers {
+		if resolved, ok := m.components.Headers[refName(h.Ref)]; ok && h.Ref != "" {
+			h = resolved
+		}
+		v := h.Example
+		if v == nil {
+			v = m.sampler.sample(h.Schema)
+		}
+		if v != nil {
+			w.Header().Set(name, fmt.Sprint(v))
+		}
+	}
+
+	mediaType := negotiate(r.Header.Get("Accept"), resp.Content)
+	if mediaType == "" {
+		w.WriteHeader(status)
+		return
+	}
+	body, err := encodeExample(mediaType, m.example(resp.Content[mediaType]))
+	if err != nil {
+		writeMockError(w, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	w.Header().Set("Content-Type", mediaType)
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(body)
+	}
+}
+
+// example returns the example of a media type, the first of its examples,
+// or a value synthesised from its schema
+func (m *mock) example(media content) interface{} {
+	if media.Example != nil {
+		return media.Example
+	}
+	names := make([]string, 0, len(media.Examples))
+	for name := range media.Examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		e := media.Examples[name]
+		if resolved, ok := m.components.Examples[refName(e.Ref)]; ok && e.Ref != "" {
+			e = resolved
+		}
+		if e.Value != nil {
+			return e.Value
+		}
+	}
+	return m.sampler.sample(media.Schema)
+}
+
+// preferredCode returns the status code of the header Prefer: code=404
+func preferredCode(r *http.Request) string {
+	for _, prefer := range r.Header.Values("Prefer") {
+		if matches := regexpPreferred.FindStringSubmatch(prefer); matches != nil {
+			return matches[1]
+		}
+	}
+	return ""
+}
+
+// chooseResponse returns the response of the preferred status code, by its
+// code, its range or the default one, or the first success response
+func chooseResponse(responses map[string]response, preferred string) (string, int, bool) {
+	if preferred != "" {
+		status, _ := strconv.Atoi(preferred)
+		for _, code := range []string{preferred, preferred[:1] + "XX", "default"} {
+			if _, ok := responses[code]; ok {
+				return code, status, true
+			}
+		}
+		return "", 0, false
+	}
+
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		if status, err := strconv.Atoi(code); err == nil && status >= 200 && status < 300 {
+			return code, status, true
+		}
+	}
+	for _, code := range []string{"2XX", "default"} {
+		if _, ok := responses[code]; ok {
+			return code, http.StatusOK, true
+		}
+	}
+	for _, code := range codes {
+		if status, err := strconv.Atoi(strings.Replace(code, "XX", "00", 1)); err == nil {
+			return code, status, true
+		}
+	}
+	return "", 0, false
+}
+
+// negotiate returns the media type of the content accepted by the request,
+// json is preferred when any is accepted
+func negotiate(accept string, c map[string]content) string {
+	if len(c) == 0 {
+		return ""
+	}
+	for _, a := range strings.Split(accept, ",") {
+		a = strings.TrimSpace(strings.SplitN(a, ";", 2)[0])
+		if a == "" || a == "*/*" {
+			continue
+		}
+		if t := matchMediaType(a, c); t != "" && !strings.Contains(t, "*") {
+			return t
+		}
+		prefix := strings.TrimSuffix(a, "*")
+		for _, t := range sortedMediaTypes(c) {
+			if strings.HasSuffix(a, "/*") && strings.HasPrefix(t, prefix) {
+				return t
+			}
+		}
+	}
+	types := sortedMediaTypes(c)
+	for _, t := range types {
+		if isJSON(t) {
+			return t
+		}
+	}
+	return types[0]
+}
+
+// matchMediaType returns the media type of the content matching t, exactly
+// or with a wildcard
+func matchMediaType(t string, c map[string]content) string {
+	if _, ok := c[t]; ok {
+		return t
+	}
+	if i := strings.Index(t, "/"); i >= 0 {
+		if _, ok := c[t[:i]+"/*"]; ok {
+			return t[:i] + "/*"
+		}
+	}
+	if _, ok := c["*/*"]; ok {
+		return "*/*"
+	}
+	return ""
+}
+
+func sortedMediaTypes(c map[string]content) []string {
+	types := make([]string, 0, len(c))
+	for t := range c {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// encodeExample returns the body of an example, a string is written as it is
+// unless the media type is json
+func encodeExample(mediaType string, value interface{}) ([]byte, error) {
+	if s, ok := value.(string); ok && !isJSON(mediaType) {
+		return []byte(s), nil
+	}
+	return json.Marshal(jsonCompatible(value))
+}
+
+func writeMockError(w http.ResponseWriter, status int, message string, errs []string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(struct {
+		Message string   `json:"message"`
+		Errors  []string `json:"errors,omitempty"`
+	}{message, errs})
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
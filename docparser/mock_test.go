@@ -0,0 +1,349 @@
+package docparser
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const mockDocument = `openapi: 3.0.0
+info:
+  title: Pets
+  version: 1.0.0
+servers:
+  - url: https://api.example.com/v1
+paths:
+  /pets:
+    get:
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+            minimum: 1
+            maximum: 100
+        - name: kind
+          in: query
+          schema:
+            type: array
+            items:
+              type: string
+              enum: [cat, dog]
+      responses:
+        "200":
+          description: The pets
+          headers:
+            X-Total:
+              schema:
+                type: integer
+                minimum: 1
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/Pet'
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses:
+        "201":
+          description: The pet
+          content:
+            application/json:
+              example:
+                id: 3fa85f64-5717-4562-b3fc-2c963f66afa6
+                name: Rex
+                kind: dog
+  /pets/mine:
+    get:
+      responses:
+        "204":
+          description: No pets
+  /pets/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: string
+          format: uuid
+    get:
+      responses:
+        "200":
+          description: The pet
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+              examples:
+                rex:
+                  value:
+                    name: Rex
+        "404":
+          $ref: '#/components/responses/NotFound'
+        default:
+          description: An error
+          content:
+            text/plain:
+              schema:
+                type: string
+components:
+  responses:
+    NotFound:
+      description: Not found
+      content:
+        application/json:
+          schema:
+            $ref: '#/components/schemas/Error'
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        id:
+          type: string
+          format: uuid
+          readOnly: true
+        name:
+          type: string
+          minLength: 2
+        kind:
+          type: string
+          enum: [cat, dog]
+        born:
+          type: string
+          format: date-time
+        tags:
+          type: object
+          additionalProperties:
+            type: string
+        parent:
+          $ref: '#/components/schemas/Pet'
+    Error:
+      type: object
+      properties:
+        code:
+          type: integer
+          minimum: 400
+        message:
+          type: string
+`
+
+func mockServer(t *testing.T) http.Handler {
+	spec := NewOpenAPI()
+	assert.NoError(t, yaml.Unmarshal([]byte(mockDocument), &spec))
+	h, err := spec.Mock()
+	assert.NoError(t, err)
+	return h
+}
+
+func TestMock(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		url         string
+		headers     map[string]string
+		body        string
+		status      int
+		header      map[string]string
+		expected    interface{}
+		contains    []string
+		contentType string
+	}{
+		{
+			name:   "synthesised from the schema",
+			method: "GET",
+			url:    "/pets?limit=10&kind=cat,dog",
+			status: 200,
+			header: map[string]string{"X-Total": "1"},
+			expected: []interface{}{map[string]interface{}{
+				"id":   "3fa85f64-5717-4562-b3fc-2c963f66afa6",
+				"name": "string",
+				"kind": "cat",
+				"born": "2006-01-02T15:04:05Z",
+				"tags": map[string]interface{}{"key": "string"},
+			}},
+		},
+		{
+			name:     "server base path",
+			method:   "GET",
+			url:      "/v1/pets/mine",
+			status:   204,
+			expected: nil,
+		},
+		{
+			name:     "example",
+			method:   "POST",
+			url:      "/pets",
+			headers:  map[string]string{"Content-Type": "application/json"},
+			body:     `{"name": "Rex", "kind": "dog"}`,
+			status:   201,
+			expected: map[string]interface{}{"id": "3fa85f64-5717-4562-b3fc-2c963f66afa6", "name": "Rex", "kind": "dog"},
+		},
+		{
+			name:     "path template",
+			method:   "GET",
+			url:      "/pets/3fa85f64-5717-4562-b3fc-2c963f66afa6",
+			status:   200,
+			expected: map[string]interface{}{"name": "Rex"},
+		},
+		{
+			name:     "preferred code",
+			method:   "GET",
+			url:      "/pets/3fa85f64-5717-4562-b3fc-2c963f66afa6",
+			headers:  map[string]string{"Prefer": "code=404"},
+			status:   404,
+			expected: map[string]interface{}{"code": float64(400), "message": "string"},
+		},
+		{
+			name:        "preferred default",
+			method:      "GET",
+			url:         "/pets/3fa85f64-5717-4562-b3fc-2c963f66afa6",
+			headers:     map[string]string{"Prefer": "code=503"},
+			status:      503,
+			contentType: "text/plain",
+		},
+		{
+			name:     "preferred code undocumented",
+			method:   "GET",
+			url:      "/pets",
+			headers:  map[string]string{"Prefer": "code=404"},
+			status:   500,
+			contains: []string{"doesn't document the response 404"},
+		},
+		{
+			name:     "invalid parameters",
+			method:   "GET",
+			url:      "/pets?limit=1000&kind=bird",
+			status:   400,
+			contains: []string{"limit must be <= 100", `kind[0] must be one of [\"cat\",\"dog\"], got \"bird\"`},
+		},
+		{
+			name:     "parameter of the wrong type",
+			method:   "GET",
+			url:      "/pets?limit=ten",
+			status:   400,
+			contains: []string{`the query parameter limit must be an integer, got \"ten\"`},
+		},
+		{
+			name:     "invalid path parameter",
+			method:   "GET",
+			url:      "/pets/rex",
+			status:   400,
+			contains: []string{`id must be a valid uuid, got \"rex\"`},
+		},
+		{
+			name:     "invalid body",
+			method:   "POST",
+			url:      "/pets",
+			headers:  map[string]string{"Content-Type": "application/json"},
+			body:     `{"name": "R", "kind": "bird", "born": "yesterday"}`,
+			status:   400,
+			contains: []string{"body.name must have at least 2 characters", "body.kind must be one of", "body.born must be a valid date-time"},
+		},
+		{
+			name:     "missing body",
+			method:   "POST",
+			url:      "/pets",
+			status:   400,
+			contains: []string{"the request body is required"},
+		},
+		{
+			name:     "unsupported content type",
+			method:   "POST",
+			url:      "/pets",
+			headers:  map[string]string{"Content-Type": "application/xml"},
+			body:     `<pet/>`,
+			status:   415,
+			contains: []string{"the content type application/xml isn't accepted"},
+		},
+		{
+			name:     "unknown path",
+			method:   "GET",
+			url:      "/owners",
+			status:   404,
+			contains: []string{"No operation matches GET /owners"},
+		},
+		{
+			name:     "method not allowed",
+			method:   "DELETE",
+			url:      "/pets",
+			status:   405,
+			header:   map[string]string{"Allow": "GET, POST"},
+			contains: []string{"The operations of /pets are GET, POST"},
+		},
+		{
+			name:    "preflight",
+			method:  "OPTIONS",
+			url:     "/pets",
+			status:  204,
+			header:  map[string]string{"Access-Control-Allow-Methods": "GET, POST", "Access-Control-Allow-Origin": "*"},
+			headers: map[string]string{"Origin": "http://localhost:3000"},
+		},
+	}
+
+	h := mockServer(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+			for k, v := range tt.header {
+				assert.Equal(t, v, w.Header().Get(k))
+			}
+			if tt.contentType != "" {
+				assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+			}
+			for _, c := range tt.contains {
+				assert.Contains(t, w.Body.String(), c)
+			}
+			if tt.contains == nil && tt.contentType == "" {
+				var body interface{}
+				if w.Body.Len() > 0 {
+					assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				}
+				assert.Equal(t, tt.expected, body)
+			}
+		})
+	}
+}
+
+func TestPathPattern(t *testing.T) {
+	tests := []struct {
+		url    string
+		path   string
+		match  bool
+		params []string
+	}{
+		{url: "/pets", path: "/pets", match: true},
+		{url: "/pets", path: "/pets/", match: true},
+		{url: "/pets", path: "/pets/1", match: false},
+		{url: "/pets/{id}", path: "/pets/1", match: true, params: []string{"id"}},
+		{url: "/pets/{id}", path: "/pets/", match: false, params: []string{"id"}},
+		{url: "/pets/{id}.{ext}", path: "/pets/1.json", match: true, params: []string{"id", "ext"}},
+		{url: "/a.b", path: "/axb", match: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url+" "+tt.path, func(t *testing.T) {
+			pattern, params := pathPattern(tt.url)
+			assert.Equal(t, tt.match, pattern.MatchString(tt.path))
+			assert.Equal(t, tt.params, params)
+		})
+	}
+}
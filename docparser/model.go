@@ -4,11 +4,13 @@ import (
 	"errors"
 	"fmt"
 	"go/ast"
-	"io/ioutil"
-	"os"
+	"go/build"
+	"go/token"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"text/template"
 
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
@@ -18,40 +20,118 @@ var (
 	regexpPath    = regexp.MustCompile("@openapi:path\n([^@]*)$")
 	regexpSchema  = regexp.MustCompile(`@openapi:schema:?(\w+)?:?(?:\[([\w,]+)\])?`)
 	regexpExample = regexp.MustCompile(`@openapi:example [^\v\n]+`)
-	regexpInfo    = regexp.MustCompile("@openapi:info\n([^@]*)$")
+	regexpInfo    = regexp.MustCompile(`@openapi:info\n([\s\S]*)$`)
 	regexpImport  = regexp.MustCompile(`import\(([^\)]+)\)`)
 	tab           = regexp.MustCompile(`\t`)
 )
 
 type openAPI struct {
-	Openapi    string
-	Info       info
-	Servers    []server
-	Paths      map[string]path
-	Tags       []tag `yaml:"tags,omitempty"`
-	Components Components
-	Security   []map[string][]string `yaml:"security,omitempty"`
-	XGroupTags []interface{}         `yaml:"x-tagGroups"`
+	Openapi      string
+	Info         info
+	Servers      []server `yaml:"servers,omitempty"`
+	Paths        paths
+	Webhooks     paths                 `yaml:"webhooks,omitempty"`
+	Tags         []tag                 `yaml:"tags,omitempty"`
+	Components   Components            `yaml:"components,omitempty"`
+	Security     []map[string][]string `yaml:"security,omitempty"`
+	ExternalDocs *externalDoc          `yaml:"externalDocs,omitempty"`
+	XGroupTags   []interface{}         `yaml:"x-tagGroups,omitempty"`
+	Extensions   extensions            `yaml:",inline"`
+
+	registeredSchemas   map[string]interface{}
+	jobs                int
+	typeDecls           map[string]typeDecl
+	funcDecls           map[string]funcDecl
+	handlers            []handler
+	defaultResponses    map[string]string
+	modules             []string
+	include             []string
+	exclude             []string
+	build               build.Context
+	types               map[string]*schema
+	infoOverride        *info
+	serversOverride     []server
+	genericNameTemplate *template.Template
+	cacheDir            string
+	// pkgPath is the import path of the package of the file parsed, see pkg
+	pkgPath string
+	// fset are the positions of the parsed files, for the errors
+	fset *token.FileSet
+	// astFiles are the cached files parsed again for their declarations
+	astFiles map[string]*ast.File
+	files    []string
+	errs     []error
+	warnings []string
+
+	hoistAnonymousStructs bool
+	fillExamples          bool
+}
+
+// typeDecl is a type declared in a parsed file, kept to instantiate generic
+// types and to expand parameters from structs. The type of a cached file is
+// known by its path only until it's needed, see typeDecl. The types are
+// keyed by the import path of their package and their name, see typeKey.
+type typeDecl struct {
+	file *ast.File
+	spec *ast.TypeSpec
+	path string
+}
+
+// typeKey returns the key of a declared type from its name, qualified with
+// the import path or the name of its package, or not qualified. A type which
+// isn't found by its import path is looked for by package name, then by name
+// when it's declared once.
+func (spec *openAPI) typeKey(name string) (string, error) {
+	if _, ok := spec.typeDecls[name]; ok {
+		return name, nil
+	}
+
+	pkg, typeName := splitTypeKey(name)
+	candidates := []string{}
+	for key := range spec.typeDecls {
+		keyPkg, keyName := splitTypeKey(key)
+		if keyName == typeName && (pkg == "" || packageName(keyPkg) == packageName(pkg)) {
+			candidates = append(candidates, key)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return "", fmt.Errorf("type %s not found", name)
+	case 1:
+		return candidates[0], nil
+	}
+	sort.Strings(candidates)
+	return "", fmt.Errorf("type %s is declared in several packages: %s", name, strings.Join(candidates, ", "))
+}
 
-	registeredSchemas map[string]interface{}
+// splitTypeKey splits the qualified name of a type into its package and its
+// name, the package is empty when the name isn't qualified
+func splitTypeKey(key string) (pkg, name string) {
+	i := strings.LastIndex(key, ".")
+	if i < 0 {
+		return "", key
+	}
+	return key[:i], key[i+1:]
 }
 
 type server struct {
 	URL         string                    `yaml:"url"`
-	Description string                    `yaml:"description"`
+	Description string                    `yaml:"description,omitempty"`
 	Variables   map[string]serverVariable `yaml:",omitempty"`
+	Extensions  extensions                `yaml:",inline"`
 }
 
 type serverVariable struct {
 	Default     string
-	Enum        []string
-	Description string
+	Enum        []string   `yaml:",omitempty"`
+	Description string     `yaml:",omitempty"`
+	Extensions  extensions `yaml:",inline"`
 }
 
 func NewOpenAPI() openAPI {
 	spec := openAPI{}
 	spec.Openapi = "3.0.0"
-	spec.Paths = make(map[string]path)
+	spec.Paths = make(paths)
 	spec.Components = Components{}
 	spec.Components.Schemas = make(map[string]interface{})
 	spec.registeredSchemas = map[string]interface{}{
@@ -59,44 +139,60 @@ func NewOpenAPI() openAPI {
 			"description": "Can be anything: string, number, array, object, etc., including `null`",
 		},
 	}
+	spec.typeDecls = make(map[string]typeDecl)
+	spec.funcDecls = make(map[string]funcDecl)
+	spec.build = build.Default
+	spec.fset = token.NewFileSet()
+	spec.genericNameTemplate = template.Must(
+		template.New("generic").Funcs(genericNameFuncs).Parse(DefaultGenericNameTemplate),
+	)
 	return spec
 }
 
 type Components struct {
-	Schemas         map[string]interface{}     // schema or composedSchema
+	Schemas         map[string]interface{}     `yaml:"schemas,omitempty"` // schema or composedSchema
+	Parameters      map[string]parameter       `yaml:"parameters,omitempty"`
+	Responses       map[string]response        `yaml:"responses,omitempty"`
+	RequestBodies   map[string]requestBody     `yaml:"requestBodies,omitempty"`
+	Headers         map[string]header          `yaml:"headers,omitempty"`
+	Examples        map[string]example         `yaml:"examples,omitempty"`
+	Links           map[string]link            `yaml:"links,omitempty"`
 	SecuritySchemes map[string]securitySchemes `yaml:"securitySchemes,omitempty"`
+	Callbacks       map[string]paths           `yaml:"callbacks,omitempty"`
+	Extensions      extensions                 `yaml:",inline"`
 }
 
 type securitySchemes struct {
-	Type   string
-	Flows  map[string]flow `yaml:"flows,omitempty"`
-	Scheme string          `yaml:"scheme,omitempty"`
+	Ref              string          `yaml:"$ref,omitempty"`
+	Type             string          `yaml:"type,omitempty"`
+	Description      string          `yaml:"description,omitempty"`
+	Name             string          `yaml:"name,omitempty"`
+	In               string          `yaml:"in,omitempty"`
+	Scheme           string          `yaml:"scheme,omitempty"`
+	BearerFormat     string          `yaml:"bearerFormat,omitempty"`
+	Flows            map[string]flow `yaml:"flows,omitempty"`
+	OpenIDConnectURL string          `yaml:"openIdConnectUrl,omitempty"`
+	Extensions       extensions      `yaml:",inline"`
 }
 
 type flow struct {
-	AuthorizationURL string            `yaml:"authorizationUrl"`
-	TokenURL         string            `yaml:"tokenUrl"`
+	AuthorizationURL string            `yaml:"authorizationUrl,omitempty"`
+	TokenURL         string            `yaml:"tokenUrl,omitempty"`
+	RefreshURL       string            `yaml:"refreshUrl,omitempty"`
 	Scopes           map[string]string `yaml:"scopes"`
-}
-
-type info struct {
-	Version     string
-	Title       string
-	Description string
-	XLogo       map[string]string `yaml:"x-logo,omitempty"`
-	Contact     map[string]string `yaml:",omitempty"`
-	Licence     map[string]string `yaml:",omitempty"`
+	Extensions       extensions        `yaml:",inline"`
 }
 
 type tag struct {
-	Name        string
-	Description string
+	Name         string
+	Description  string       `yaml:",omitempty"`
+	ExternalDocs *externalDoc `yaml:"externalDocs,omitempty"`
+	Extensions   extensions   `yaml:",inline"`
 }
 
 func newEntity() schema {
 	e := schema{}
 	e.Properties = make(map[string]*schema)
-	e.Items = make(map[string]interface{})
 	return e
 }
 
@@ -112,13 +208,16 @@ type metadata struct {
 }
 
 type composedSchema struct {
-	metadata `yaml:"-"`
-	AllOf    []*schema `yaml:"allOf"`
+	metadata   `yaml:"-"`
+	AllOf      []*schema   `yaml:"allOf"`
+	Example    interface{} `yaml:"example,omitempty"`
+	Extensions extensions  `yaml:",inline"`
 }
 
 type externalDoc struct {
-	Description string `yaml:",omitempty"`
-	Url         string `yaml:"url,omitempty"`
+	Description string     `yaml:",omitempty"`
+	Url         string     `yaml:"url,omitempty"`
+	Extensions  extensions `yaml:",inline"`
 }
 
 func (c *composedSchema) RealName() string {
@@ -144,17 +243,83 @@ func (c *composedSchema) SetCustomName(customName string) {
 
 type schema struct {
 	metadata             `yaml:"-"`
-	Nullable             *bool                  `yaml:"nullable,omitempty"`
-	Required             []string               `yaml:"required,omitempty"`
-	Type                 string                 `yaml:",omitempty"`
-	Items                map[string]interface{} `yaml:",omitempty"`
-	Format               string                 `yaml:"format,omitempty"`
-	Ref                  string                 `yaml:"$ref,omitempty"`
-	Enum                 []string               `yaml:",omitempty"`
-	Properties           map[string]*schema     `yaml:",omitempty"`
-	AdditionalProperties *schema                `yaml:"additionalProperties,omitempty"`
-	OneOf                []schema               `yaml:"oneOf,omitempty"`
-	Example              interface{}            `yaml:"example,omitempty"`
+	Title                string             `yaml:"title,omitempty"`
+	Description          string             `yaml:"description,omitempty"`
+	Nullable             *bool              `yaml:"nullable,omitempty"`
+	Required             []string           `yaml:"required,omitempty"`
+	Type                 string             `yaml:",omitempty"`
+	Items                *schema            `yaml:",omitempty"`
+	MinItems             *int               `yaml:"minItems,omitempty"`
+	MaxItems             *int               `yaml:"maxItems,omitempty"`
+	UniqueItems          bool               `yaml:"uniqueItems,omitempty"`
+	Format               string             `yaml:"format,omitempty"`
+	Ref                  string             `yaml:"$ref,omitempty"`
+	Enum                 []interface{}      `yaml:",omitempty"`
+	Default              interface{}        `yaml:"default,omitempty"`
+	MultipleOf           *float64           `yaml:"multipleOf,omitempty"`
+	Maximum              *float64           `yaml:"maximum,omitempty"`
+	ExclusiveMaximum     bool               `yaml:"exclusiveMaximum,omitempty"`
+	Minimum              *float64           `yaml:"minimum,omitempty"`
+	ExclusiveMinimum     bool               `yaml:"exclusiveMinimum,omitempty"`
+	MaxLength            *int               `yaml:"maxLength,omitempty"`
+	MinLength            *int               `yaml:"minLength,omitempty"`
+	Pattern              string             `yaml:"pattern,omitempty"`
+	Properties           map[string]*schema `yaml:",omitempty"`
+	MaxProperties        *int               `yaml:"maxProperties,omitempty"`
+	MinProperties        *int               `yaml:"minProperties,omitempty"`
+	AdditionalProperties *schema            `yaml:"additionalProperties,omitempty"`
+	AllOf                []*schema          `yaml:"allOf,omitempty"`
+	OneOf                []*schema          `yaml:"oneOf,omitempty"`
+	AnyOf                []*schema          `yaml:"anyOf,omitempty"`
+	Not                  *schema            `yaml:"not,omitempty"`
+	Discriminator        *discriminator     `yaml:"discriminator,omitempty"`
+	ReadOnly             bool               `yaml:"readOnly,omitempty"`
+	WriteOnly            bool               `yaml:"writeOnly,omitempty"`
+	XML                  *xml               `yaml:"xml,omitempty"`
+	ExternalDocs         *externalDoc       `yaml:"externalDocs,omitempty"`
+	Example              interface{}        `yaml:"example,omitempty"`
+	Deprecated           bool               `yaml:"deprecated,omitempty"`
+	Extensions           extensions         `yaml:",inline"`
+
+	// boolean is set by the boolean schemas, i.e. additionalProperties: false
+	boolean *bool
+	// examplePos is the position of the @openapi:example annotation, and
+	// exampleAt its position read from the cache, see checkExamples
+	examplePos token.Pos
+	exampleAt  string
+}
+
+type discriminator struct {
+	PropertyName string            `yaml:"propertyName"`
+	Mapping      map[string]string `yaml:"mapping,omitempty"`
+}
+
+type xml struct {
+	Name       string     `yaml:"name,omitempty"`
+	Namespace  string     `yaml:"namespace,omitempty"`
+	Prefix     string     `yaml:"prefix,omitempty"`
+	Attribute  bool       `yaml:"attribute,omitempty"`
+	Wrapped    bool       `yaml:"wrapped,omitempty"`
+	Extensions extensions `yaml:",inline"`
+}
+
+// UnmarshalYAML accepts the boolean schemas
+func (s *schema) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var b bool
+	if err := unmarshal(&b); err == nil {
+		s.boolean = &b
+		return nil
+	}
+	type plain schema
+	return unmarshal((*plain)(s))
+}
+
+func (s schema) MarshalYAML() (interface{}, error) {
+	if s.boolean != nil {
+		return *s.boolean, nil
+	}
+	type plain schema
+	return plain(s), nil
 }
 
 func (s *schema) RealName() string {
@@ -193,60 +358,104 @@ func (e BuildError) Error() string {
 	return err
 }
 
-// /pets: operation
-type path map[string]operation
-
 type operation struct {
 	Summary      string `yaml:",omitempty"`
-	Description  string
+	Description  string `yaml:",omitempty"`
 	ID           string `yaml:"operationId,omitempty"`
 	Responses    map[string]response
-	Tags         []string `yaml:",omitempty"`
-	Parameters   []parameter
-	RequestBody  requestBody           `yaml:"requestBody,omitempty"`
-	Security     []map[string][]string `yaml:",omitempty"`
-	Headers      map[string]header     `yaml:",omitempty"`
-	Deprecated   bool                  `yaml:",omitempty"`
-	Servers      []server              `yaml:",omitempty"`
-	ExternalDocs externalDoc           `yaml:"externalDocs,omitempty"`
+	Tags         []string               `yaml:",omitempty"`
+	Parameters   []parameter            `yaml:",omitempty"`
+	RequestBody  requestBody            `yaml:"requestBody,omitempty"`
+	Callbacks    map[string]paths       `yaml:"callbacks,omitempty"`
+	Security     *[]map[string][]string `yaml:",omitempty"`
+	Headers      map[string]header      `yaml:",omitempty"`
+	Deprecated   bool                   `yaml:",omitempty"`
+	Servers      []server               `yaml:",omitempty"`
+	ExternalDocs externalDoc            `yaml:"externalDocs,omitempty"`
+	Extensions   extensions             `yaml:",inline"`
+
+	// ParametersFrom are the structs expanded into parameters, it's kept in
+	// the cache and emptied by expandParameters
+	ParametersFrom stringList `yaml:"parametersFrom,omitempty"`
+	// RequestBodyFrom is the struct expanded into a form request body, it's
+	// kept in the cache and emptied by expandRequestBodies
+	RequestBodyFrom *formBody `yaml:"requestBodyFrom,omitempty"`
 }
 
 type parameter struct {
-	Example     string `yaml:"example,omitempty"`
-	In          string
-	Name        string
-	Schema      schema `yaml:",omitempty"`
-	Required    bool
-	Description string
+	Ref             string             `yaml:"$ref,omitempty"`
+	Example         interface{}        `yaml:"example,omitempty"`
+	Examples        map[string]example `yaml:"examples,omitempty"`
+	In              string
+	Name            string
+	Schema          *schema            `yaml:",omitempty"`
+	Content         map[string]content `yaml:"content,omitempty"`
+	Required        bool               `yaml:",omitempty"`
+	Description     string             `yaml:",omitempty"`
+	Deprecated      bool               `yaml:"deprecated,omitempty"`
+	AllowEmptyValue bool               `yaml:"allowEmptyValue,omitempty"`
+	Style           string             `yaml:"style,omitempty"`
+	Explode         *bool              `yaml:"explode,omitempty"`
+	AllowReserved   bool               `yaml:"allowReserved,omitempty"`
+	Extensions      extensions         `yaml:",inline"`
 }
 
 type requestBody struct {
-	Description string
-	Required    bool
-	Content     map[string]content
+	Ref         string             `yaml:"$ref,omitempty"`
+	Description string             `yaml:",omitempty"`
+	Required    bool               `yaml:",omitempty"`
+	Content     map[string]content `yaml:",omitempty"`
+	Extensions  extensions         `yaml:",inline"`
 }
 
 type response struct {
-	Content     map[string]content
+	Ref         string             `yaml:"$ref,omitempty"`
+	Content     map[string]content `yaml:",omitempty"`
 	Description string
 	Headers     map[string]header `yaml:",omitempty"`
+	Links       map[string]link   `yaml:"links,omitempty"`
+	Extensions  extensions        `yaml:",inline"`
 }
 
 type header struct {
-	Description string `yaml:",omitempty"`
-	Schema      schema `yaml:",omitempty"`
+	Ref             string             `yaml:"$ref,omitempty"`
+	Description     string             `yaml:",omitempty"`
+	Required        bool               `yaml:"required,omitempty"`
+	Deprecated      bool               `yaml:"deprecated,omitempty"`
+	AllowEmptyValue bool               `yaml:"allowEmptyValue,omitempty"`
+	Style           string             `yaml:"style,omitempty"`
+	Explode         *bool              `yaml:"explode,omitempty"`
+	AllowReserved   bool               `yaml:"allowReserved,omitempty"`
+	Schema          *schema            `yaml:",omitempty"`
+	Example         interface{}        `yaml:"example,omitempty"`
+	Examples        map[string]example `yaml:"examples,omitempty"`
+	Content         map[string]content `yaml:"content,omitempty"`
+	Extensions      extensions         `yaml:",inline"`
 }
 
 type content struct {
-	Schema schema
+	Schema     *schema             `yaml:",omitempty"`
+	Example    interface{}         `yaml:"example,omitempty"`
+	Examples   map[string]example  `yaml:"examples,omitempty"`
+	Encoding   map[string]encoding `yaml:"encoding,omitempty"`
+	Extensions extensions          `yaml:",inline"`
+}
+
+type encoding struct {
+	ContentType   string            `yaml:"contentType,omitempty"`
+	Headers       map[string]header `yaml:"headers,omitempty"`
+	Style         string            `yaml:"style,omitempty"`
+	Explode       *bool             `yaml:"explode,omitempty"`
+	AllowReserved bool              `yaml:"allowReserved,omitempty"`
+	Extensions    extensions        `yaml:",inline"`
 }
 
 func validatePath(path string, parseVendors []string) bool {
 	// vendoring path
-	if strings.Contains(path, "vendor") {
+	if pkg, ok := vendoredPackage(path); ok {
 		found := false
 		for _, vendorPath := range parseVendors {
-			if strings.Contains(path, vendorPath) {
+			if importPathHasPrefix(pkg, vendorPath) {
 				found = true
 				break
 			}
@@ -261,45 +470,121 @@ func validatePath(path string, parseVendors []string) bool {
 		return false
 	}
 
+	// test file
+	if strings.HasSuffix(path, "_test.go") {
+		return false
+	}
+
 	// dot file
-	if strings.HasPrefix(path, ".") {
+	if strings.HasPrefix(filepath.Base(path), ".") {
 		return false
 	}
 
 	return true
 }
 
-func (spec *openAPI) Parse(path string, parseVendors []string, vendorsPath string, exitNonZeroOnError bool) {
-	// fset := token.NewFileSet() // positions are relative to fset
-
-	walker := func(path string, f os.FileInfo, err error) error {
-		if validatePath(path, parseVendors) {
-			astFile, _ := parseFile(path)
-			infosErrors := spec.parseInfos(astFile)
-			schemasErrors := spec.parseSchemas(astFile)
-			pathErrors := spec.parsePaths(astFile)
-			if exitNonZeroOnError &&
-				(len(infosErrors) > 0 || len(schemasErrors) > 0 || len(pathErrors) > 0) {
-				return errors.New("errors while generating OpenAPI schema")
+// Parse parses the folders of paths, the modules and the vendored packages.
+// The files are parsed concurrently, each one in its own document, and merged
+// into the document in the order of the files. With exitNonZeroOnError, Parse
+// stops and returns an error once the files or the types have errors.
+func (spec *openAPI) Parse(paths []string, parseVendors []string, vendorsPath string, exitNonZeroOnError bool) error {
+	roots := append(append(append([]string{}, paths...), spec.modules...), vendorsPath)
+
+	files := []string{}
+	seen := make(map[string]bool)
+	for _, root := range roots {
+		rootFiles, err := spec.collectFiles(root, parseVendors)
+		if err != nil {
+			return err
+		}
+		// a vendored package is found in the folder and the vendors path
+		for _, f := range rootFiles {
+			abs, err := filepath.Abs(f)
+			if err != nil {
+				abs = f
+			}
+			if !seen[abs] {
+				seen[abs] = true
+				files = append(files, f)
 			}
 		}
-		return nil
 	}
 
-	err := filepath.Walk(path, walker)
-	if err != nil {
-		os.Exit(1)
+	spec.files = files
+	for _, result := range spec.parseFiles(files) {
+		spec.errs = append(spec.errs, result.errs...)
+		if result.spec != nil {
+			spec.errs = append(spec.errs, spec.mergeFile(result.spec)...)
+		}
+	}
+	if exitNonZeroOnError && len(spec.errs) > 0 {
+		return errorsFound(spec.errs)
 	}
 
-	err = filepath.Walk(vendorsPath, walker)
-	if err != nil {
-		os.Exit(1)
+	spec.resolveTypeRefs()
+	spec.warnings = append(spec.warnings, spec.analyseHandlers()...)
+	spec.registerTypes()
+	spec.errs = append(spec.errs, spec.expandParameters()...)
+	spec.errs = append(spec.errs, spec.expandRequestBodies()...)
+	spec.errs = append(spec.errs, spec.instantiateGenerics()...)
+	if exitNonZeroOnError && len(spec.errs) > 0 {
+		return errorsFound(spec.errs)
 	}
 
 	spec.composeSpecSchemas()
+	spec.errs = append(spec.errs, spec.checkExamples()...)
+	if spec.fillExamples {
+		if err := spec.FillExamples(); err != nil {
+			spec.errs = append(spec.errs, err)
+		}
+	}
+	spec.applyOverrides()
+	spec.upgradeVersion()
+	spec.dropUnknownFields()
+	return nil
+}
+
+// errorsFound returns the error of Parse when it stops on errs
+func errorsFound(errs []error) error {
+	return fmt.Errorf("%d errors found while parsing", len(errs))
+}
+
+// openAPI31 is the version of the documents using the fields added by
+// OpenAPI 3.1
+const openAPI31 = "3.1.0"
+
+// upgradeVersion sets the version of a 3.0 document to 3.1.0 when it uses
+// fields which only exist in OpenAPI 3.1: the webhooks, the summary of the
+// info or the identifier of the license
+func (spec *openAPI) upgradeVersion() {
+	if len(spec.Webhooks) == 0 && !spec.Info.usesOpenAPI31() || !strings.HasPrefix(spec.Openapi, "3.0") {
+		return
+	}
+	logrus.
+		WithField("version", openAPI31).
+		Info("Upgrading the OpenAPI version of the document for its 3.1 fields")
+	spec.Openapi = openAPI31
+}
+
+// Files returns the files parsed by Parse
+func (spec *openAPI) Files() []string {
+	return spec.files
+}
+
+// Errors returns the errors of Parse
+func (spec *openAPI) Errors() []error {
+	return spec.errs
+}
+
+// Warnings returns the warnings of Parse: the conflicts of the info and the
+// analysis of the handlers
+func (spec *openAPI) Warnings() []string {
+	return spec.warnings
 }
 
 func (spec *openAPI) parsePaths(f *ast.File) (errs []error) {
+	docs := spec.registerFuncs(f)
+
 	for _, s := range f.Comments {
 		t := s.Text()
 		// Test if comments is a path
@@ -312,7 +597,7 @@ func (spec *openAPI) parsePaths(f *ast.File) (errs []error) {
 		content := tab.ReplaceAllString(string(a[1]), "  ")
 
 		// Unmarshal yaml
-		p := make(map[string]path)
+		p := make(paths)
 		err := yaml.Unmarshal([]byte(content), &p)
 		if err != nil {
 			logrus.
@@ -327,30 +612,56 @@ func (spec *openAPI) parsePaths(f *ast.File) (errs []error) {
 			continue
 		}
 
+		// Structs expanded into parameters and request body of every operation
+		parametersFrom := parametersAnnotations(t)
+		form, err := formAnnotation(t)
+		if err != nil {
+			logrus.
+				WithError(err).
+				WithField("content", t).
+				Error("Unable to parse form")
+			errs = append(errs, &BuildError{
+				Err:     err,
+				Content: t,
+				Message: "unable to parse form",
+			})
+		}
+
 		for url, path := range p {
-			// Path already exists in the spec
-			if _, ok := spec.Paths[url]; ok {
-				// Iterate over verbs
-				for currentVerb, currentDesc := range path {
-					if _, operationAlreadyExists := spec.Paths[url][currentVerb]; operationAlreadyExists {
-						logrus.
-							WithField("url", url).
-							WithField("verb", currentVerb).
-							Error("Verb for this path already exists")
-						errs = append(errs, &BuildError{
-							Err:     errors.New("verb for this path already exists"),
-							Content: fmt.Sprintf("url: %s, verb: %s", url, currentVerb),
-						})
-						continue
+			for verb, op := range path.Operations {
+				op.ParametersFrom = append(append(stringList{}, parametersFrom...), op.ParametersFrom...)
+				if op.RequestBodyFrom == nil && form != nil {
+					f := *form
+					op.RequestBodyFrom = &f
+				}
+				if err := spec.addDefaultResponses(&op); err != nil {
+					logrus.
+						WithError(err).
+						WithField("url", url).
+						WithField("verb", verb).
+						Error("Unable to add default responses")
+					errs = append(errs, &BuildError{
+						Err:     err,
+						Content: fmt.Sprintf("url: %s, verb: %s", url, verb),
+						Message: "unable to add default responses",
+					})
+				}
+				path.Operations[verb] = op
+			}
+
+			// The handler is analysed once the helpers of its package are known
+			if fd, ok := docs[s]; ok {
+				for _, verb := range verbs {
+					if _, ok := path.Operations[verb]; ok {
+						spec.handlers = append(spec.handlers, handler{pkg: spec.pkg(f), file: f, decl: fd, url: url, verb: verb})
 					}
-					spec.Paths[url][currentVerb] = currentDesc
 				}
-			} else {
-				spec.Paths[url] = path
 			}
 
+			errs = append(errs, spec.addPath(url, path)...)
+
 			keys := []string{}
-			for k := range path {
+			for k := range path.Operations {
 				keys = append(keys, k)
 			}
 
@@ -364,33 +675,66 @@ func (spec *openAPI) parsePaths(f *ast.File) (errs []error) {
 	return
 }
 
-func (spec *openAPI) replaceSchemaNameToCustom(s *schema) {
-	if s == nil {
-		return
+// addPath adds a path item to the paths, the operations of a path which
+// already exists are added to it
+func (spec *openAPI) addPath(url string, p path) (errs []error) {
+	current, ok := spec.Paths[url]
+	if !ok {
+		spec.Paths[url] = p
+		return nil
 	}
 
-	for _, property := range s.Properties {
-		spec.replaceSchemaNameToCustom(property)
+	current.mergeFields(p)
+	if current.Operations == nil && len(p.Operations) > 0 {
+		current.Operations = make(map[string]operation)
 	}
-	spec.replaceSchemaNameToCustom(s.AdditionalProperties)
+	for _, verb := range verbs {
+		op, ok := p.Operations[verb]
+		if !ok {
+			continue
+		}
+		if _, operationAlreadyExists := current.Operations[verb]; operationAlreadyExists {
+			logrus.
+				WithField("url", url).
+				WithField("verb", verb).
+				Error("Verb for this path already exists")
+			errs = append(errs, &BuildError{
+				Err:     errors.New("verb for this path already exists"),
+				Content: fmt.Sprintf("url: %s, verb: %s", url, verb),
+			})
+			continue
+		}
+		current.Operations[verb] = op
+	}
+	spec.Paths[url] = current
+	return errs
+}
 
-	refSplit := strings.Split(s.Ref, "/")
+func (spec *openAPI) replaceSchemaNameToCustom(s *schema) {
+	mapSchemaRefs(s, spec.customSchemaRef)
+}
+
+func (spec *openAPI) customSchemaRef(ref string) string {
+	// the real name of a generic type has the import paths of its types
+	refSplit := strings.SplitN(ref, "/", 4)
 	if len(refSplit) != 4 {
-		return
+		return ref
 	}
 	if replacementSchema, found := spec.registeredSchemas[refSplit[3]]; found {
 		meta, ok := replacementSchema.(metaSchema)
 		if !ok {
-			return
+			return ref
 		}
 		if meta.CustomName() != "" {
 			refSplit[3] = meta.CustomName()
 		}
 	}
-	s.Ref = strings.Join(refSplit, "/")
+	return strings.Join(refSplit, "/")
 }
 
 func (spec *openAPI) composeSpecSchemas() {
+	spec.breakCompositionCycles()
+
 	for realName, registeredSchema := range spec.registeredSchemas {
 		if realName == "AnyValue" {
 			spec.Components.Schemas[realName] = registeredSchema
@@ -416,6 +760,9 @@ func (spec *openAPI) composeSpecSchemas() {
 		}
 		spec.Components.Schemas[name] = registeredSchema
 	}
+
+	spec.mapPathsRefs(spec.customSchemaRef)
+	spec.mapComponentsRefs(spec.customSchemaRef)
 }
 
 func (spec *openAPI) parseMaps(f *ast.File, mp *ast.MapType) (*schema, []error) {
@@ -434,7 +781,49 @@ func (spec *openAPI) parseMaps(f *ast.File, mp *ast.MapType) (*schema, []error)
 
 }
 
-func (spec *openAPI) parseStructs(f *ast.File, tpe *ast.StructType) (interface{}, []error) {
+func (spec *openAPI) parseStructs(f *ast.File, tpe *ast.StructType, name string) (interface{}, []error) {
+	return parseStructType(f, tpe, spec.anonymousStructs(name))
+}
+
+// anonymousStructs tells how the anonymous structs found in the fields of the
+// schema called name are handled
+func (spec *openAPI) anonymousStructs(name string) anonymousStructs {
+	anon := anonymousStructs{name: name}
+	if spec.hoistAnonymousStructs {
+		anon.hoist = spec.hoistSchema
+	}
+	return anon
+}
+
+// SetHoistAnonymousStructs registers the anonymous structs found in the fields
+// of a schema as component schemas named after the schema and the field, i.e.
+// Pet_Anonymous, rather than inlining them.
+func (spec *openAPI) SetHoistAnonymousStructs(hoist bool) {
+	spec.hoistAnonymousStructs = hoist
+}
+
+// hoistSchema registers an anonymous struct as a component schema and returns
+// a reference to it
+func (spec *openAPI) hoistSchema(name string, entity interface{}) *schema {
+	if _, ok := spec.registeredSchemas[name]; ok {
+		logrus.
+			WithField("name", name).
+			Warn("Schema already exists, the anonymous struct replaces it")
+	}
+
+	if mtd, ok := entity.(metaSchema); ok {
+		mtd.SetCustomName(name)
+	}
+	spec.registeredSchemas[name] = entity
+
+	p := schema{Ref: schemaRefPrefix + name}
+	p.metadata.RealName = name
+	return &p
+}
+
+// parseStructType parses the fields of a struct, embedded fields are composed
+// with allOf
+func parseStructType(f *ast.File, tpe *ast.StructType, anon anonymousStructs) (interface{}, []error) {
 	errors := make([]error, 0)
 
 	var cs *composedSchema
@@ -443,7 +832,7 @@ func (spec *openAPI) parseStructs(f *ast.File, tpe *ast.StructType) (interface{}
 
 	for _, fld := range tpe.Fields.List {
 
-		example, err := spec.parseExample(fld.Doc.Text(), fld.Type)
+		ext, err := parseExtensions(fld.Doc.Text())
 		if err != nil {
 			errors = append(errors, err)
 		}
@@ -457,7 +846,7 @@ func (spec *openAPI) parseStructs(f *ast.File, tpe *ast.StructType) (interface{}
 				e.Required = append(e.Required, j.name)
 			}
 
-			p, err := parseNamedType(f, fld.Type, nil)
+			p, err := parseType(f, fld.Type, nil, anon.field(fld.Names[0].Name))
 			if err != nil {
 				logrus.WithError(err).WithField("field", fld.Names[0]).Error("Can't parse the type of field in struct")
 				errors = append(errors, BuildError{
@@ -468,13 +857,11 @@ func (spec *openAPI) parseStructs(f *ast.File, tpe *ast.StructType) (interface{}
 				continue
 			}
 
-			if example != nil {
-				p.Example = example
-			}
-
 			if len(j.enum) > 0 {
-				p.Enum = j.enum
+				setEnum(p, j.enum, j.name)
 			}
+			setExample(p, fld.Doc)
+			setExtensions(p, ext)
 
 			if p != nil {
 				e.Properties[j.name] = p
@@ -488,7 +875,7 @@ func (spec *openAPI) parseStructs(f *ast.File, tpe *ast.StructType) (interface{}
 				}
 			}
 
-			p, err := parseNamedType(f, fld.Type, nil)
+			p, err := parseType(f, fld.Type, nil, anon)
 			if err != nil {
 				logrus.WithError(err).WithField("field", fld.Type).Error("Can't parse the type of composed field in struct")
 				errors = append(errors, BuildError{
@@ -498,9 +885,7 @@ func (spec *openAPI) parseStructs(f *ast.File, tpe *ast.StructType) (interface{}
 				continue
 			}
 
-			if example != nil {
-				p.Example = example
-			}
+			setExample(p, fld.Doc)
 
 			cs.AllOf = append(cs.AllOf, p)
 		}
@@ -514,16 +899,30 @@ func (spec *openAPI) parseStructs(f *ast.File, tpe *ast.StructType) (interface{}
 	}
 }
 
-func (spec *openAPI) parseExample(comment string, exampleType ast.Expr) (interface{}, error) {
-	exampleLines := regexpExample.FindSubmatch([]byte(comment))
-	if len(exampleLines) == 0 {
-		return nil, nil
+// parseExample returns the value of the @openapi:example annotation of a
+// comment, and its position
+func parseExample(doc *ast.CommentGroup) (string, token.Pos, bool) {
+	if doc == nil {
+		return "", token.NoPos, false
 	}
+	for _, c := range doc.List {
+		if loc := regexpExample.FindStringIndex(c.Text); loc != nil {
+			example := strings.TrimSpace(c.Text[loc[0]+len("@openapi:example ") : loc[1]])
+			return strings.TrimSuffix(example, "*/"), c.Slash + token.Pos(loc[0]), true
+		}
+	}
+	return "", token.NoPos, false
+}
 
-	line := string(exampleLines[0])
-	example := line[len("@openapi:example "):]
-
-	return convertExample(example, exampleType)
+// setExample sets the example of the @openapi:example annotation of a
+// comment, converted to the type of the schema
+func setExample(s *schema, doc *ast.CommentGroup) {
+	example, pos, ok := parseExample(doc)
+	if !ok || s == nil {
+		return
+	}
+	s.Example = convertExample(example, s)
+	s.examplePos = pos
 }
 
 func (spec *openAPI) parseSchemas(f *ast.File) (errors []error) {
@@ -539,13 +938,19 @@ func (spec *openAPI) parseSchemas(f *ast.File) (errors []error) {
 
 			// If the node is a Type
 			if ts, ok := spc.(*ast.TypeSpec); ok {
-				var entity interface{}
 				realName := ts.Name.Name
 				entityName := realName
 
+				spec.typeDecls[spec.pkg(f)+"."+realName] = typeDecl{file: f, spec: ts}
+
+				// Generic types are registered once instantiated
+				if typeParamsCount(ts) > 0 {
+					continue
+				}
+
 				// Looking for openapi entity
 				a := regexpSchema.FindSubmatch([]byte(t))
-				example, err := spec.parseExample(t, ts.Type)
+				ext, err := parseExtensions(t)
 				if err != nil {
 					errors = append(errors, err)
 				}
@@ -560,76 +965,16 @@ func (spec *openAPI) parseSchemas(f *ast.File) (errors []error) {
 					}
 				}
 
-				switch n := ts.Type.(type) {
-				case *ast.MapType:
-					var errs []error
-					entity, errs = spec.parseMaps(f, n)
-					if len(errs) != 0 {
-						errors = append(errors, errs...)
-					}
-
-					logrus.
-						WithField("name", entityName).
-						Info("Parsing Schema")
-
-				case *ast.StructType:
-					var errs []error
-					entity, errs = spec.parseStructs(f, n)
-					if len(errs) != 0 {
-						errors = append(errors, errs...)
-					}
-
-					mtd, ok := entity.(metaSchema)
-					if ok {
-						mtd.SetCustomName(entityName)
-					}
-					logrus.
-						WithField("name", entityName).
-						Info("Parsing Schema")
-
-				case *ast.ArrayType:
-					e := newEntity()
-					p, err := parseNamedType(f, n.Elt, nil)
-					if err != nil {
-						logrus.WithError(err).Error("Can't parse the type of field in struct")
-						errors = append(errors, &BuildError{
-							Err:     err,
-							Message: "Can't parse the type of field in struct",
-						})
-						continue
-					}
-
-					e.Type = "array"
-					if p.Ref != "" {
-						e.Items["$ref"] = p.Ref
-					} else {
-						e.Items["type"] = p.Type
-					}
-					entity = &e
-
-				default:
-					p, err := parseNamedType(f, ts.Type, nil)
-					if err != nil {
-						logrus.WithError(err).Error("can't parse custom type")
-						errors = append(errors, BuildError{
-							Err:     err,
-							Message: "can't parse custom type",
-						})
-						continue
-					}
-					p.SetCustomName(entityName)
-
-					logrus.
-						WithField("name", entityName).
-						Info("Parsing Schema")
-					entity = p
+				entity, errs := spec.parseSchemaType(f, ts.Type, entityName)
+				if len(errs) != 0 {
+					errors = append(errors, errs...)
 				}
 
 				if entity != nil {
-					s, ok := entity.(*schema)
-					if ok && example != nil {
-						s.Example = example
+					if s, ok := entity.(*schema); ok {
+						setExample(s, gd.Doc)
 					}
+					setExtensions(entity, ext)
 					spec.registeredSchemas[realName] = entity
 				}
 			}
@@ -638,107 +983,76 @@ func (spec *openAPI) parseSchemas(f *ast.File) (errors []error) {
 	return
 }
 
-func (spec *openAPI) AddOperation(path, verb string, a operation) {
-	if _, ok := spec.Paths[path]; !ok {
-		spec.Paths[path] = make(map[string]operation)
-	}
-	spec.Paths[path][verb] = a
-}
+// parseSchemaType builds the schema of a type declaration, the returned entity
+// is nil when the type can't be parsed
+func (spec *openAPI) parseSchemaType(f *ast.File, expr ast.Expr, entityName string) (interface{}, []error) {
+	errors := make([]error, 0)
 
-func (spec *openAPI) parseInfos(f *ast.File) (errors []error) {
-	for _, s := range f.Comments {
-		t := s.Text()
-		// Test if comment is an info block
-		a := regexpInfo.FindSubmatch([]byte(t))
-		if len(a) == 0 {
-			continue
+	switch n := expr.(type) {
+	case *ast.MapType:
+		entity, errs := spec.parseMaps(f, n)
+		if len(errs) != 0 {
+			errors = append(errors, errs...)
 		}
 
-		// Replacing tab with spaces
-		content := tab.ReplaceAllString(string(a[1]), "  ")
+		logrus.
+			WithField("name", entityName).
+			Info("Parsing Schema")
+		return entity, errors
 
-		// Unmarshal yaml
-		infos := info{}
-		err := yaml.Unmarshal([]byte(content), &infos)
-		if err != nil {
-			logrus.
-				WithError(err).
-				WithField("content", content).
-				Error("Unable to unmarshal infos")
-			errors = append(errors, &BuildError{
-				Err:     err,
-				Content: content,
-				Message: "Unable to unmarshal infos",
-			})
-			continue
+	case *ast.StructType:
+		entity, errs := spec.parseStructs(f, n, entityName)
+		if len(errs) != 0 {
+			errors = append(errors, errs...)
 		}
 
-		version := infos.Version
-		if spec.Info.Version != "" && spec.Info.Version != version {
-			logrus.
-				WithField("version", spec.Info.Version).
-				WithField("version_scanned", version).
-				Warn("Version already exists and is different!")
-		} else {
-			logrus.
-				WithField("field", "version").
-				WithField("value", version).
-				Info("Parsing info")
-			spec.Info.Version = version
+		mtd, ok := entity.(metaSchema)
+		if ok {
+			mtd.SetCustomName(entityName)
 		}
+		logrus.
+			WithField("name", entityName).
+			Info("Parsing Schema")
+		return entity, errors
 
-		title := infos.Title
-		if spec.Info.Title != "" && spec.Info.Title != title {
-			logrus.
-				WithField("title", spec.Info.Title).
-				WithField("title_scanned", title).
-				Warn("Title already exists and is different!")
-		} else {
-			logrus.
-				WithField("field", "title").
-				WithField("value", title).
-				Info("Parsing info")
-			spec.Info.Title = title
+	case *ast.ArrayType:
+		p, err := parseType(f, n, nil, spec.anonymousStructs(entityName).field("Item"))
+		if err != nil {
+			logrus.WithError(err).Error("Can't parse the type of field in struct")
+			errors = append(errors, &BuildError{
+				Err:     err,
+				Message: "Can't parse the type of field in struct",
+			})
+			return nil, errors
 		}
+		return p, errors
 
-		description := infos.Description
-		if spec.Info.Description != "" && spec.Info.Description != description {
-			logrus.
-				WithField("description", spec.Info.Description).
-				WithField("description_scanned", description).
-				Warn("Description already exists and is different!")
-		} else {
-			p, err := parseImportContentPath(description)
-			// no need to import a file
-			if err != nil {
-				logrus.
-					WithField("field", "description").
-					WithField("value", description).
-					Info("Parsing info")
-				spec.Info.Description = description
-			} else {
-				c, err := ioutil.ReadFile(p)
-
-				if err != nil {
-					logrus.
-						WithField("File", p).
-						WithError(err).
-						Error("Could not import file")
-					return
-				}
-
-				logrus.
-					WithField("field", "description").
-					WithField("value", "content of file: "+p).
-					Info("Parsing info")
-				spec.Info.Description = string(c)
-			}
+	default:
+		p, err := parseNamedType(f, expr, nil)
+		if err != nil {
+			logrus.WithError(err).Error("can't parse custom type")
+			errors = append(errors, BuildError{
+				Err:     err,
+				Message: "can't parse custom type",
+			})
+			return nil, errors
 		}
+		p.SetCustomName(entityName)
 
-		spec.Info.XLogo = infos.XLogo
+		logrus.
+			WithField("name", entityName).
+			Info("Parsing Schema")
+		return p, errors
+	}
+}
 
+func (spec *openAPI) AddOperation(url, verb string, a operation) {
+	p := spec.Paths[url]
+	if p.Operations == nil {
+		p.Operations = make(map[string]operation)
 	}
-	return
+	p.Operations[verb] = a
+	spec.Paths[url] = p
 }
 
 func parseImportContentPath(str string) (string, error) {
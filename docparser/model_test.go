@@ -2,9 +2,11 @@ package docparser
 
 import (
 	"go/ast"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
 )
 
 type parseInfosTestCase struct {
@@ -17,7 +19,8 @@ type parseInfosTestCase struct {
 
 func Test_validatePath(t *testing.T) {
 	type args struct {
-		path string
+		path    string
+		vendors []string
 	}
 	tests := []struct {
 		name string
@@ -45,10 +48,54 @@ func Test_validatePath(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"Dot File in folder",
+			args{
+				path: "api/.#handlers.go",
+			},
+			false,
+		},
+		{
+			"Relative path",
+			args{
+				path: "../api/handlers.go",
+			},
+			true,
+		},
+		{
+			"Test file",
+			args{
+				path: "/foo/bar/handlers_test.go",
+			},
+			false,
+		},
+		{
+			"Folder containing vendor",
+			args{
+				path: "/foo/vendorx/handlers.go",
+			},
+			true,
+		},
+		{
+			"Parsed vendor",
+			args{
+				path:    "vendor/github.com/my/library/models/pet.go",
+				vendors: []string{"github.com/my/library"},
+			},
+			true,
+		},
+		{
+			"Vendor with the same prefix",
+			args{
+				path:    "vendor/github.com/my/library-v2/pet.go",
+				vendors: []string{"github.com/my/library"},
+			},
+			false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := validatePath(tt.args.path, []string{}); got != tt.want {
+			if got := validatePath(tt.args.path, tt.args.vendors); got != tt.want {
 				t.Errorf("validatePath() = %v, want %v", got, tt.want)
 			}
 		})
@@ -158,6 +205,172 @@ func TestParseInfos(t *testing.T) {
 	}
 }
 
+func TestParseInfosMerge(t *testing.T) {
+	first := &ast.CommentGroup{
+		List: []*ast.Comment{
+			{Text: "// @openapi:info"},
+			{Text: `// version: "1.0.1"`},
+			{Text: `// title: Petstore`},
+			{Text: `// contact:`},
+			{Text: `//   name: API Support`},
+			{Text: `// license:`},
+			{Text: `//   name: Apache 2.0`},
+			{Text: `//   identifier: Apache-2.0`},
+			{Text: `// x-logo:`},
+			{Text: `//   url: https://example.com/logo.png`},
+		},
+	}
+	second := &ast.CommentGroup{
+		List: []*ast.Comment{
+			{Text: "// @openapi:info"},
+			{Text: `// version: "1.0.1"`},
+			{Text: `// summary: A pet store`},
+			{Text: `// termsOfService: https://example.com/terms`},
+			{Text: `// contact:`},
+			{Text: `//   email: support@example.com`},
+			{Text: `// license:`},
+			{Text: `//   name: MIT`},
+			{Text: `//   url: https://opensource.org/licenses/MIT`},
+			{Text: `// x-audience: public`},
+			{Text: `// x-logo:`},
+			{Text: `//   url: https://example.com/other.png`},
+		},
+	}
+
+	spec := NewOpenAPI()
+	assert.Empty(t, spec.parseInfos(&ast.File{Comments: []*ast.CommentGroup{first}}))
+	// the conflicts are warnings, the first values are kept
+	assert.Empty(t, spec.parseInfos(&ast.File{Comments: []*ast.CommentGroup{second}}))
+	assert.Equal(t, []string{
+		"info license.name is already set to Apache 2.0, MIT is ignored",
+		"info license.identifier is already set, license.url https://opensource.org/licenses/MIT is ignored",
+		"info x-logo is already set to map[url:https://example.com/logo.png], map[url:https://example.com/other.png] is ignored",
+	}, spec.Warnings())
+
+	b, err := yaml.Marshal(spec.Info)
+	assert.NoError(t, err)
+	assert.Equal(t, `title: Petstore
+summary: A pet store
+termsOfService: https://example.com/terms
+contact:
+  name: API Support
+  email: support@example.com
+license:
+  name: Apache 2.0
+  identifier: Apache-2.0
+version: 1.0.1
+x-audience: public
+x-logo:
+  url: https://example.com/logo.png
+`, string(b))
+
+	spec.upgradeVersion()
+	assert.Equal(t, "3.1.0", spec.Openapi)
+}
+
+func TestParseInfosWarnings(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, map[string]string{
+		filepath.Join(dir, "a.go"): `package api
+
+// @openapi:info
+// title: Petstore
+// version: 1.0.0
+
+// @openapi:info
+// version: 1.0.1
+`,
+		filepath.Join(dir, "b.go"): `package api
+
+// @openapi:info
+// title: Stores
+`,
+	})
+
+	// the warnings of a file are read from the cache as well
+	cacheDir := filepath.Join(dir, "cache")
+	for _, cache := range []string{"", cacheDir, cacheDir} {
+		spec := NewOpenAPI()
+		spec.SetCacheDir(cache)
+		assert.NoError(t, spec.Parse([]string{dir}, nil, "vendor", true))
+		assert.Empty(t, spec.Errors())
+		assert.Equal(t, []string{
+			"info version is already set to 1.0.0, 1.0.1 is ignored",
+			"info title is already set to Petstore, Stores is ignored",
+		}, spec.Warnings())
+		assert.Equal(t, "Petstore", spec.Info.Title)
+	}
+}
+
+func TestParseInfosExtensions(t *testing.T) {
+	first := &ast.CommentGroup{
+		List: []*ast.Comment{
+			{Text: "// @openapi:info"},
+			{Text: `// title: Petstore`},
+			{Text: `// contact:`},
+			{Text: `//   name: API Support`},
+			{Text: `//   x-team: pets`},
+			{Text: `// license:`},
+			{Text: `//   name: MIT`},
+			{Text: `//   x-spdx: MIT`},
+		},
+	}
+	second := &ast.CommentGroup{
+		List: []*ast.Comment{
+			{Text: "// @openapi:info"},
+			{Text: `// contact:`},
+			{Text: `//   x-team: stores`},
+			{Text: `//   x-slack: "#pets"`},
+			{Text: `// license:`},
+			{Text: `//   x-spdx: MIT`},
+		},
+	}
+
+	spec := NewOpenAPI()
+	assert.Empty(t, spec.parseInfos(&ast.File{Comments: []*ast.CommentGroup{first}}))
+	assert.Empty(t, spec.parseInfos(&ast.File{Comments: []*ast.CommentGroup{second}}))
+	assert.Equal(t, []string{"info contact.x-team is already set to pets, stores is ignored"}, spec.Warnings())
+
+	spec.Info.override(info{
+		Contact: &contact{Extensions: extensions{"x-team": "stores"}},
+		License: &license{Extensions: extensions{"x-url": "https://spdx.org/licenses/MIT"}},
+	})
+	b, err := yaml.Marshal(spec.Info)
+	assert.NoError(t, err)
+	assert.Equal(t, `title: Petstore
+contact:
+  name: API Support
+  x-slack: '#pets'
+  x-team: stores
+license:
+  name: MIT
+  x-spdx: MIT
+  x-url: https://spdx.org/licenses/MIT
+version: ""
+`, string(b))
+}
+
+func TestUpgradeVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		info     info
+		expected string
+	}{
+		{name: "3.0 fields", info: info{Title: "Pets", License: &license{Name: "MIT"}}, expected: "3.0.0"},
+		{name: "summary", info: info{Summary: "A pet store"}, expected: "3.1.0"},
+		{name: "license identifier", info: info{License: &license{Name: "MIT", Identifier: "MIT"}}, expected: "3.1.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := NewOpenAPI()
+			spec.Info = tt.info
+			spec.upgradeVersion()
+			assert.Equal(t, tt.expected, spec.Openapi)
+		})
+	}
+}
+
 func Test_parseImportContentPath(t *testing.T) {
 	type args struct {
 		str string
@@ -198,3 +411,118 @@ func Test_parseImportContentPath(t *testing.T) {
 		})
 	}
 }
+
+type parseAnonymousStructsTestCase struct {
+	description     string
+	hoist           bool
+	expectedSchemas map[string]string
+}
+
+func TestParseAnonymousStructs(t *testing.T) {
+	anonymous := `allOf:
+- $ref: '#/components/schemas/Foo'
+- required:
+  - field
+  type: object
+  properties:
+    field:
+      type: string
+    kind:
+      type: string
+      enum:
+      - A
+      - B
+`
+	data := `type: object
+properties:
+  id:
+    type: string
+`
+
+	testCases := []parseAnonymousStructsTestCase{
+		{
+			description: "Should inline anonymous structs",
+			expectedSchemas: map[string]string{
+				"AnonymousArray": `type: object
+properties:
+  data:
+    type: array
+    items:
+      type: object
+      properties:
+        id:
+          type: string
+`,
+			},
+		},
+		{
+			description: "Should hoist anonymous structs",
+			hoist:       true,
+			expectedSchemas: map[string]string{
+				"AnonymousArray": `type: object
+properties:
+  data:
+    type: array
+    items:
+      $ref: '#/components/schemas/AnonymousArray_Data'
+`,
+				"AnonymousArray_Data": data,
+				"Pet_Anonymous":       anonymous,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			f, err := parseFile("datatest/user.go")
+			assert.NoError(t, err)
+
+			spec := NewOpenAPI()
+			spec.SetHoistAnonymousStructs(tc.hoist)
+			spec.parseSchemas(f)
+			spec.composeSpecSchemas()
+
+			pet, err := yaml.Marshal(spec.Components.Schemas["Pet"].(*schema).Properties["anonymous"])
+			assert.NoError(t, err)
+			if tc.hoist {
+				assert.Equal(t, "$ref: '#/components/schemas/Pet_Anonymous'\n", string(pet))
+			} else {
+				assert.Equal(t, anonymous, string(pet))
+			}
+
+			for name, expected := range tc.expectedSchemas {
+				b, err := yaml.Marshal(spec.Components.Schemas[name])
+				assert.NoError(t, err)
+				assert.Equal(t, expected, string(b))
+			}
+		})
+	}
+}
+
+func TestTypeKey(t *testing.T) {
+	spec := NewOpenAPI()
+	for _, key := range []string{"example.com/pets.Pet", "example.com/pets/a.Page", "example.com/pets/b.Page"} {
+		spec.typeDecls[key] = typeDecl{}
+	}
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "example.com/pets.Pet", expected: "example.com/pets.Pet"},
+		{name: "pets.Pet", expected: "example.com/pets.Pet"},
+		{name: "Pet", expected: "example.com/pets.Pet"},
+		{name: "a.Page", expected: "example.com/pets/a.Page"},
+		{name: "Page"},
+		{name: "c.Page"},
+		{name: "Missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := spec.typeKey(tt.name)
+			assert.Equal(t, tt.expected, key)
+			assert.Equal(t, tt.expected == "", err != nil)
+		})
+	}
+}
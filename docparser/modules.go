@@ -0,0 +1,180 @@
+package docparser
+
+import (
+	"errors"
+	"fmt"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// SetModules sets the modules parsed with the folders, or packages of
+// modules, i.e. github.com/acme/models. They are found offline with the go.mod
+// of dir: in the folder of a replace directive, or in the module cache for the
+// required version.
+func (spec *openAPI) SetModules(dir string, modules []string) error {
+	if len(modules) == 0 {
+		spec.modules = nil
+		return nil
+	}
+
+	goMod, err := findGoMod(dir)
+	if err != nil {
+		return err
+	}
+
+	dirs := make([]string, 0, len(modules))
+	for _, m := range modules {
+		d, err := resolveModule(goMod, m)
+		if err != nil {
+			return err
+		}
+		dirs = append(dirs, d)
+	}
+	spec.modules = dirs
+	return nil
+}
+
+// findGoMod returns the go.mod of the module of dir
+func findGoMod(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		p := filepath.Join(dir, "go.mod")
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("go.mod not found, modules can only be parsed in a module")
+		}
+		dir = parent
+	}
+}
+
+// moduleCache returns the folder of the module cache
+func moduleCache() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	gopath := filepath.SplitList(build.Default.GOPATH)
+	if len(gopath) == 0 {
+		return ""
+	}
+	return filepath.Join(gopath[0], "pkg", "mod")
+}
+
+// resolveModule returns the folder of the package pkg, the module of the
+// go.mod itself or a package of a module it requires
+func resolveModule(goMod, pkg string) (string, error) {
+	data, err := ioutil.ReadFile(goMod)
+	if err != nil {
+		return "", err
+	}
+	f, err := modfile.Parse(goMod, data, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if f.Module != nil && importPathHasPrefix(pkg, f.Module.Mod.Path) {
+		return packageDir(filepath.Dir(goMod), subPackage(pkg, f.Module.Mod.Path), pkg)
+	}
+
+	// the module providing the package is the one with the longest path
+	var mod module.Version
+	for _, r := range f.Require {
+		if importPathHasPrefix(pkg, r.Mod.Path) && len(r.Mod.Path) > len(mod.Path) {
+			mod = r.Mod
+		}
+	}
+	if mod.Path == "" {
+		return "", fmt.Errorf("module of %s isn't required by %s", pkg, goMod)
+	}
+	sub := subPackage(pkg, mod.Path)
+
+	// a replace of the version is used over a replace of every version
+	var replace *modfile.Replace
+	for _, r := range f.Replace {
+		if r.Old.Path == mod.Path && (r.Old.Version == mod.Version || r.Old.Version == "" && replace == nil) {
+			replace = r
+		}
+	}
+	if replace != nil {
+		if modfile.IsDirectoryPath(replace.New.Path) {
+			dir := replace.New.Path
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(filepath.Dir(goMod), dir)
+			}
+			return packageDir(dir, sub, pkg)
+		}
+		mod = replace.New
+	}
+
+	path, err := module.EscapePath(mod.Path)
+	if err != nil {
+		return "", err
+	}
+	version, err := module.EscapeVersion(mod.Version)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(moduleCache(), path+"@"+version)
+	if _, err := os.Stat(dir); err != nil {
+		return "", fmt.Errorf("%s@%s isn't in the module cache, download it with go mod download: %w", mod.Path, mod.Version, err)
+	}
+	return packageDir(dir, sub, pkg)
+}
+
+// packageDir returns the folder of the package pkg, in the folder sub of the
+// module in dir
+func packageDir(dir, sub, pkg string) (string, error) {
+	dir = filepath.Join(dir, sub)
+	if _, err := os.Stat(dir); err != nil {
+		return "", fmt.Errorf("package %s not found: %w", pkg, err)
+	}
+	return dir, nil
+}
+
+// subPackage returns the folder of the package pkg in the module mod
+func subPackage(pkg, mod string) string {
+	return filepath.FromSlash(strings.TrimPrefix(strings.TrimPrefix(pkg, mod), "/"))
+}
+
+// packagePath returns the import path of the package of a file: the folder
+// after vendor/, the path of its module followed by its folder in the module,
+// or its folder out of a module
+func packagePath(path string) string {
+	if pkg, ok := vendoredPackage(path); ok {
+		return pkg
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return filepath.ToSlash(filepath.Dir(path))
+	}
+	goMod, err := findGoMod(dir)
+	if err != nil {
+		return filepath.ToSlash(dir)
+	}
+	data, err := ioutil.ReadFile(goMod)
+	if err != nil {
+		return filepath.ToSlash(dir)
+	}
+	mod := modfile.ModulePath(data)
+	rel, err := filepath.Rel(filepath.Dir(goMod), dir)
+	if mod == "" || err != nil {
+		return filepath.ToSlash(dir)
+	}
+	if rel == "." {
+		return mod
+	}
+	return mod + "/" + filepath.ToSlash(rel)
+}
@@ -0,0 +1,130 @@
+package docparser
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testGoMod = `module github.com/acme/pets
+
+go 1.18
+
+require (
+	github.com/Acme/models v1.2.0
+	github.com/acme/events v0.3.0
+	github.com/acme/errors v1.0.0
+	github.com/acme/auth v0.1.0
+)
+
+replace github.com/acme/events => ../events
+
+replace github.com/acme/errors v1.0.0 => github.com/acme/errors-fork v1.0.1
+`
+
+func writeFiles(t testing.TB, files map[string]string) {
+	for path, content := range files {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestResolveModule(t *testing.T) {
+	dir := t.TempDir()
+	cache := filepath.Join(dir, "cache")
+	t.Setenv("GOMODCACHE", cache)
+
+	writeFiles(t, map[string]string{
+		filepath.Join(dir, "pets", "go.mod"):                                          testGoMod,
+		filepath.Join(dir, "events", "go.mod"):                                        "module github.com/acme/events\n",
+		filepath.Join(cache, "github.com", "!acme", "models@v1.2.0", "go.mod"):        "module github.com/Acme/models\n",
+		filepath.Join(cache, "github.com", "!acme", "models@v1.2.0", "dto", "pet.go"): "package dto\n",
+		filepath.Join(cache, "github.com", "acme", "errors-fork@v1.0.1", "go.mod"):    "module github.com/acme/errors-fork\n",
+	})
+	goMod := filepath.Join(dir, "pets", "go.mod")
+
+	tests := []struct {
+		name    string
+		pkg     string
+		dir     string
+		wantErr bool
+	}{
+		{name: "module cache", pkg: "github.com/Acme/models", dir: filepath.Join(cache, "github.com", "!acme", "models@v1.2.0")},
+		{name: "package", pkg: "github.com/Acme/models/dto", dir: filepath.Join(cache, "github.com", "!acme", "models@v1.2.0", "dto")},
+		{name: "replaced by a folder", pkg: "github.com/acme/events", dir: filepath.Join(dir, "events")},
+		{name: "replaced by a module", pkg: "github.com/acme/errors", dir: filepath.Join(cache, "github.com", "acme", "errors-fork@v1.0.1")},
+		{name: "main module", pkg: "github.com/acme/pets", dir: filepath.Join(dir, "pets")},
+		{name: "not required", pkg: "github.com/acme/other", wantErr: true},
+		{name: "unknown package", pkg: "github.com/Acme/models/other", wantErr: true},
+		{name: "unknown package in a folder", pkg: "github.com/acme/events/v2", wantErr: true},
+		{name: "not downloaded", pkg: "github.com/acme/auth", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := resolveModule(goMod, tc.pkg)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.dir, d)
+		})
+	}
+}
+
+func TestParseModules(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, map[string]string{
+		filepath.Join(dir, "pets", "go.mod"):   testGoMod,
+		filepath.Join(dir, "pets", "main.go"):  "package main\n",
+		filepath.Join(dir, "events", "go.mod"): "module github.com/acme/events\n",
+		filepath.Join(dir, "events", "pet.go"): `package events
+
+// PetCreated is sent when a pet is created
+// @openapi:schema
+type PetCreated struct {
+	ID string ` + "`json:\"id\"`" + `
+}
+`,
+	})
+
+	spec := NewOpenAPI()
+	c := DefaultConfig()
+	c.Paths = []string{filepath.Join(dir, "pets")}
+	c.VendorsPath = filepath.Join(dir, "pets", "vendor")
+	c.Modules = []string{"github.com/acme/events"}
+	assert.NoError(t, spec.Configure(c))
+	spec.Parse(c.Paths, c.Vendors, c.VendorsPath, false)
+
+	assert.Contains(t, spec.Components.Schemas, "PetCreated")
+
+	c.Paths = []string{dir}
+	assert.Error(t, spec.Configure(c))
+}
+
+func TestPackagePath(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, map[string]string{
+		filepath.Join(dir, "pets", "go.mod"): testGoMod,
+	})
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: filepath.Join(dir, "pets", "main.go"), expected: "github.com/acme/pets"},
+		{path: filepath.Join(dir, "pets", "api", "v1", "pets.go"), expected: "github.com/acme/pets/api/v1"},
+		{path: filepath.Join(dir, "pets", "vendor", "github.com", "acme", "models", "pet.go"), expected: "github.com/acme/models"},
+		{path: filepath.Join(dir, "other", "pets.go"), expected: filepath.ToSlash(filepath.Join(dir, "other"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, packagePath(tt.path))
+		})
+	}
+}
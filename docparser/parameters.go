@@ -0,0 +1,267 @@
+package docparser
+
+import (
+	"fmt"
+	"go/ast"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+var regexpParameters = regexp.MustCompile(`@openapi:parameters ([\w.]+)`)
+
+// parameterTags are the struct tags of the binding libraries and the location
+// of the parameters they bind, by order of precedence
+var parameterTags = []struct {
+	tag string
+	in  string
+}{
+	{"query", "query"},   // echo
+	{"form", "query"},    // gin, echo
+	{"schema", "query"},  // gorilla/schema
+	{"uri", "path"},      // gin
+	{"param", "path"},    // echo
+	{"path", "path"},     // chi render, go-openapi
+	{"header", "header"}, // gin, echo
+	{"cookie", "cookie"},
+}
+
+// stringList unmarshals a yaml string or a list of strings
+type stringList []string
+
+func (l *stringList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err == nil {
+		*l = stringList{s}
+		return nil
+	}
+
+	var list []string
+	if err := unmarshal(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// parametersAnnotations returns the structs given with @openapi:parameters in
+// a comment
+func parametersAnnotations(comment string) []string {
+	names := []string{}
+	for _, m := range regexpParameters.FindAllStringSubmatch(comment, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// expandParameters adds the parameters of the structs given with
+// @openapi:parameters or parametersFrom to the operations, the ones of the
+// webhooks and of the callbacks included
+func (spec *openAPI) expandParameters() (errs []error) {
+	spec.walkOperations(func(location, verb string, op *operation) {
+		for _, name := range op.ParametersFrom {
+			params, err := spec.structParameters(name, map[string]bool{})
+			if err != nil {
+				logrus.
+					WithError(err).
+					WithField("location", location).
+					WithField("verb", verb).
+					Error("Can't expand parameters")
+				errs = append(errs, BuildError{
+					Err:     err,
+					Content: fmt.Sprintf("%s, verb: %s", location, verb),
+					Message: "can't expand parameters",
+				})
+				continue
+			}
+
+			for _, param := range params {
+				if hasParameter(op.Parameters, param) {
+					continue
+				}
+				op.Parameters = append(op.Parameters, param)
+			}
+		}
+		op.ParametersFrom = nil
+	})
+	return errs
+}
+
+// hasParameter tells if a parameter with the same location and name already
+// exists
+func hasParameter(params []parameter, param parameter) bool {
+	for _, p := range params {
+		if p.In == param.In && p.Name == param.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// structParameters returns a parameter for each field of a struct bound from
+// the query, the path, the headers or the cookies
+func (spec *openAPI) structParameters(name string, visited map[string]bool) ([]parameter, error) {
+	fields, err := spec.structFields(name, visited)
+	if err != nil {
+		return nil, err
+	}
+
+	params := []parameter{}
+	for _, sf := range fields {
+		param, ok, err := parseParameterField(sf.file, sf.field)
+		if err != nil {
+			return nil, fmt.Errorf("field %s of %s: %w", sf.field.Names[0].Name, sf.owner, err)
+		}
+		if ok {
+			params = append(params, param)
+		}
+	}
+	return params, nil
+}
+
+// structField is an exported field of a parsed struct
+type structField struct {
+	file  *ast.File
+	field *ast.Field
+	// owner is the name of the struct declaring the field
+	owner string
+}
+
+// structFields returns the exported fields of a struct, the fields of the
+// embedded structs included
+func (spec *openAPI) structFields(name string, visited map[string]bool) ([]structField, error) {
+	key, err := spec.typeKey(name)
+	if err != nil {
+		return nil, fmt.Errorf("struct %s not found: %w", name, err)
+	}
+	decl, ok := spec.typeDecl(key)
+	if !ok {
+		return nil, fmt.Errorf("struct %s not found", name)
+	}
+	st, ok := decl.spec.Type.(*ast.StructType)
+	if !ok || typeParamsCount(decl.spec) > 0 {
+		return nil, fmt.Errorf("type %s is not a struct", name)
+	}
+	visited[key] = true
+	_, name = splitTypeKey(key)
+	pkg, _ := splitTypeKey(key)
+
+	fields := []structField{}
+	for _, fld := range st.Fields.List {
+		// embedded struct
+		if len(fld.Names) == 0 {
+			t := fld.Type
+			if star, ok := t.(*ast.StarExpr); ok {
+				t = star.X
+			}
+			embedded, err := typeExprString(qualifyTypeExpr(t, pkg, importNames(decl.file), nil))
+			if err != nil {
+				return nil, err
+			}
+			embedded, err = spec.typeKey(embedded)
+			if err != nil || visited[embedded] {
+				continue
+			}
+			f, err := spec.structFields(embedded, visited)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, f...)
+			continue
+		}
+
+		if !fld.Names[0].IsExported() {
+			continue
+		}
+		fields = append(fields, structField{file: decl.file, field: fld, owner: name})
+	}
+	return fields, nil
+}
+
+// parseParameterField builds the parameter of a struct field, ok is false when
+// the field isn't bound by a parameter tag
+func parseParameterField(f *ast.File, fld *ast.Field) (param parameter, ok bool, err error) {
+	if fld.Tag == nil {
+		return param, false, nil
+	}
+	tv, err := strconv.Unquote(fld.Tag.Value)
+	if err != nil {
+		return param, false, err
+	}
+	st := reflect.StructTag(tv)
+
+	for _, pt := range parameterTags {
+		value, found := st.Lookup(pt.tag)
+		if !found {
+			continue
+		}
+
+		options := strings.Split(value, ",")
+		if options[0] == "-" {
+			return param, false, nil
+		}
+
+		param.In = pt.in
+		param.Name = options[0]
+		if param.Name == "" {
+			param.Name = fld.Names[0].Name
+		}
+		for _, o := range options[1:] {
+			if o == "required" {
+				param.Required = true
+			}
+		}
+		ok = true
+		break
+	}
+	if !ok {
+		return param, false, nil
+	}
+
+	s, err := parseNamedType(f, fld.Type, nil)
+	if err != nil {
+		return param, false, err
+	}
+	param.Schema = s
+
+	required, enum := parseValidateTag(st.Get("validate"))
+	bindingRequired, bindingEnum := parseValidateTag(st.Get("binding"))
+	if required || bindingRequired || param.In == "path" {
+		param.Required = true
+	}
+	if len(bindingEnum) > 0 {
+		enum = bindingEnum
+	}
+	if len(enum) > 0 {
+		setEnum(param.Schema, enum, param.Name)
+	}
+
+	setExample(param.Schema, fld.Doc)
+
+	param.Extensions, err = parseExtensions(fld.Doc.Text())
+	if err != nil {
+		return param, false, err
+	}
+
+	param.Description = fieldDescription(fld)
+	return param, true, nil
+}
+
+// fieldDescription returns the comment of a field without the annotations
+func fieldDescription(fld *ast.Field) string {
+	lines := []string{}
+	for _, l := range strings.Split(fld.Doc.Text(), "\n") {
+		l = strings.TrimSpace(l)
+		if l == "" || strings.HasPrefix(l, "@openapi:") {
+			continue
+		}
+		lines = append(lines, l)
+	}
+	if len(lines) == 0 {
+		return strings.TrimSpace(fld.Comment.Text())
+	}
+	return strings.Join(lines, " ")
+}
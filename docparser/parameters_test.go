@@ -0,0 +1,216 @@
+package docparser
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestExpandParameters(t *testing.T) {
+	f, err := parseFile("datatest/parameters.go")
+	assert.NoError(t, err)
+
+	spec := NewOpenAPI()
+	assert.Empty(t, spec.parseSchemas(f))
+	assert.Empty(t, spec.parsePaths(f))
+	assert.Empty(t, spec.expandParameters())
+	assert.Empty(t, spec.checkExamples())
+
+	op := spec.Paths["/devices/{deviceId}/pets"].Operations["get"]
+	assert.Nil(t, op.ParametersFrom)
+
+	limitExample := int64(20)
+	expected := []parameter{
+		{In: "query", Name: "limit", Description: "Maximum number of items", Schema: &schema{Type: "integer", Example: limitExample}},
+		{In: "query", Name: "cursor", Description: "Cursor of the next page", Schema: &schema{Type: "string"}},
+		{In: "path", Name: "deviceId", Required: true, Schema: &schema{Type: "integer"}},
+		{In: "query", Name: "tag", Required: true, Schema: &schema{Type: "array", Items: &schema{Type: "string"}}},
+		{In: "query", Name: "kind", Schema: &schema{Type: "string", Enum: []interface{}{"cat", "dog"}}},
+		{In: "header", Name: "X-Request-ID", Schema: &schema{Type: "string"}},
+	}
+	assert.Equal(t, expected, op.Parameters)
+}
+
+func TestExpandParametersFrom(t *testing.T) {
+	src := `package test
+
+type Filters struct {
+	Limit int    ` + "`query:\"limit\"`" + `
+	Name  string ` + "`query:\"name,required\"`" + `
+}
+
+// @openapi:path
+// /pets:
+//	get:
+//		parametersFrom: Filters
+//		parameters:
+//			- in: query
+//			  name: limit
+//			  description: Overridden
+//	post:
+//		parametersFrom:
+//			- Unknown
+//		callbacks:
+//			onCreated:
+//				'{$request.body#/callbackUrl}':
+//					post:
+//						parametersFrom: Missing
+//						responses:
+//							"200":
+//								description: Received
+func Pets() {}
+
+// @openapi:webhook petsFound
+//	post:
+//		parametersFrom: Filters
+//		responses:
+//			"200":
+//				description: Received
+func SendPets() {}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+	assert.NoError(t, err)
+
+	spec := NewOpenAPI()
+	spec.parseSchemas(f)
+	assert.Empty(t, spec.parsePaths(f))
+	assert.Empty(t, spec.parseWebhooks(f))
+	errs := spec.expandParameters()
+	assert.Len(t, errs, 2)
+	assert.Equal(t, "url: /pets, verb: post", errs[0].(BuildError).Content)
+	assert.Equal(t, "url: /pets, verb: post, callback: onCreated, url: {$request.body#/callbackUrl}, verb: post", errs[1].(BuildError).Content)
+
+	params := spec.Paths["/pets"].Operations["get"].Parameters
+	assert.Len(t, params, 2)
+	assert.Equal(t, "Overridden", params[0].Description)
+	assert.Equal(t, "name", params[1].Name)
+	assert.True(t, params[1].Required)
+
+	params = spec.Webhooks["petsFound"].Operations["post"].Parameters
+	assert.Len(t, params, 2)
+	assert.Equal(t, "limit", params[0].Name)
+
+	b, err := yaml.Marshal(spec)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), "parametersFrom")
+}
+
+type parseParameterFieldTestCase struct {
+	description       string
+	fieldType         ast.Expr
+	tag               string
+	expectedOk        bool
+	expectedIn        string
+	expectedName      string
+	expectedRequired  bool
+	expectedEnum      []interface{}
+	expectedItemsEnum []interface{}
+	expectedErrorText string
+}
+
+func TestParseParameterField(t *testing.T) {
+	testCases := []parseParameterFieldTestCase{
+		{
+			description: "Should ignore a field without tag",
+		},
+		{
+			description: "Should ignore a field bound from the body",
+			tag:         "`json:\"name\"`",
+		},
+		{
+			description: "Should ignore a field with an ignored parameter tag",
+			tag:         "`query:\"-\"`",
+		},
+		{
+			description:  "Should use the name of the field when the tag has no name",
+			tag:          "`form:\",omitempty\"`",
+			expectedOk:   true,
+			expectedIn:   "query",
+			expectedName: "Field",
+		},
+		{
+			description:      "Should set required from the gorilla/schema tag option",
+			tag:              "`schema:\"field,required\"`",
+			expectedOk:       true,
+			expectedIn:       "query",
+			expectedName:     "field",
+			expectedRequired: true,
+		},
+		{
+			description:      "Should always require a path parameter",
+			tag:              "`param:\"id\"`",
+			expectedOk:       true,
+			expectedIn:       "path",
+			expectedName:     "id",
+			expectedRequired: true,
+		},
+		{
+			description:      "Should read enum and required from validate tag",
+			tag:              "`cookie:\"session\" validate:\"required,enum=a b\"`",
+			expectedOk:       true,
+			expectedIn:       "cookie",
+			expectedName:     "session",
+			expectedRequired: true,
+			expectedEnum:     []interface{}{"a", "b"},
+		},
+		{
+			description:       "Should set the enum on the items of an array",
+			fieldType:         &ast.ArrayType{Elt: &ast.Ident{Name: "int"}},
+			tag:               "`query:\"sizes\" validate:\"oneof=1 2\"`",
+			expectedOk:        true,
+			expectedIn:        "query",
+			expectedName:      "sizes",
+			expectedItemsEnum: []interface{}{int64(1), int64(2)},
+		},
+		{
+			description:  "Should ignore the enum of a $ref",
+			fieldType:    &ast.Ident{Name: "Status"},
+			tag:          "`query:\"status\" validate:\"oneof=a b\"`",
+			expectedOk:   true,
+			expectedIn:   "query",
+			expectedName: "status",
+		},
+		{
+			description:       "Should fail with invalid tag",
+			tag:               "query",
+			expectedErrorText: "invalid syntax",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			fld := &ast.Field{
+				Names: []*ast.Ident{{Name: "Field"}},
+				Type:  tc.fieldType,
+			}
+			if fld.Type == nil {
+				fld.Type = &ast.Ident{Name: "string"}
+			}
+			if tc.tag != "" {
+				fld.Tag = &ast.BasicLit{Value: tc.tag}
+			}
+
+			param, ok, err := parseParameterField(nil, fld)
+			if tc.expectedErrorText != "" {
+				assert.EqualError(t, err, tc.expectedErrorText)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedOk, ok)
+			if !ok {
+				return
+			}
+			assert.Equal(t, tc.expectedIn, param.In)
+			assert.Equal(t, tc.expectedName, param.Name)
+			assert.Equal(t, tc.expectedRequired, param.Required)
+			assert.Equal(t, tc.expectedEnum, param.Schema.Enum)
+			if param.Schema.Items != nil {
+				assert.Equal(t, tc.expectedItemsEnum, param.Schema.Items.Enum)
+			}
+		})
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/sirupsen/logrus"
 )
 
 var enumRegex = regexp.MustCompile(`enum=([\w ]+)`)
@@ -20,9 +22,13 @@ func parseFile(path string) (*ast.File, error) {
 	if err != nil {
 		return nil, err
 	}
+	return parseSource(token.NewFileSet(), "", data)
+}
 
-	fset := token.NewFileSet() // positions are relative to fset
-	return parser.ParseFile(fset, "", data, parser.ParseComments)
+// parseSource parses the content of the file path, the positions are
+// relative to fset
+func parseSource(fset *token.FileSet, path string, data []byte) (*ast.File, error) {
+	return parser.ParseFile(fset, path, data, parser.ParseComments)
 }
 
 type jsonTagInfo struct {
@@ -51,24 +57,8 @@ func parseJSONTag(field *ast.Field) (j jsonTagInfo, err error) {
 				j.required = false
 				return j, nil
 			} else if jsonName != "" {
-				required := false
-				// https://github.com/go-playground/validator
-				// check if validate attr is active
-				validateData := strings.Split(st.Get("validate"), ",")
-				for _, v := range validateData {
-					if v == "required" {
-						required = true
-					}
-					if matches := enumRegex.FindStringSubmatch(v); len(matches) > 0 {
-						j.enum = strings.Fields(matches[1])
-					}
-					if matches := oneOfRegex.FindStringSubmatch(v); len(matches) > 0 {
-						j.enum = strings.Fields(matches[1])
-					}
-				}
-
+				j.required, j.enum = parseValidateTag(st.Get("validate"))
 				j.name = jsonName
-				j.required = required
 				j.ignore = false
 
 				return j, nil
@@ -78,7 +68,76 @@ func parseJSONTag(field *ast.Field) (j jsonTagInfo, err error) {
 	return j, nil
 }
 
+// parseValidateTag reads the required and enum rules of a validation tag
+// https://github.com/go-playground/validator
+func parseValidateTag(tag string) (required bool, enum []string) {
+	for _, v := range strings.Split(tag, ",") {
+		if v == "required" {
+			required = true
+		}
+		if matches := enumRegex.FindStringSubmatch(v); len(matches) > 0 {
+			enum = strings.Fields(matches[1])
+		}
+		if matches := oneOfRegex.FindStringSubmatch(v); len(matches) > 0 {
+			enum = strings.Fields(matches[1])
+		}
+	}
+	return required, enum
+}
+
+// enumValues returns the values of an enum read from a tag as values of the
+// type of the schema, i.e. the integers of oneof=1 2
+func enumValues(enum []string, s *schema) []interface{} {
+	values := make([]interface{}, 0, len(enum))
+	for _, v := range enum {
+		values = append(values, convertValue(nil, s, v, 0))
+	}
+	return values
+}
+
+// setEnum sets the enum read from a tag of the field on its schema, or on the
+// items of an array. The enum of a $ref is ignored, the siblings of a $ref
+// are ignored by the tools.
+func setEnum(s *schema, enum []string, field string) {
+	for s.Type == "array" && s.Items != nil {
+		s = s.Items
+	}
+	if s.Ref != "" {
+		logrus.
+			WithField("field", field).
+			WithField("ref", s.Ref).
+			Warn("The enum of a $ref is ignored")
+		return
+	}
+	s.Enum = enumValues(enum, s)
+}
+
+// anonymousStructs tells how the anonymous structs found while parsing a type
+// are handled: inlined, or hoisted into a component schema called name when
+// hoist is set
+type anonymousStructs struct {
+	name  string
+	hoist func(name string, entity interface{}) *schema
+}
+
+// field returns how the anonymous structs found in a field are handled
+func (a anonymousStructs) field(name string) anonymousStructs {
+	return anonymousStructs{name: a.name + "_" + name, hoist: a.hoist}
+}
+
+// inlineSchema returns a parsed struct as a schema which can be used in place
+func inlineSchema(entity interface{}) *schema {
+	if cs, ok := entity.(*composedSchema); ok {
+		return &schema{AllOf: cs.AllOf}
+	}
+	return entity.(*schema)
+}
+
 func parseNamedType(gofile *ast.File, expr ast.Expr, sel *ast.Ident) (*schema, error) {
+	return parseType(gofile, expr, sel, anonymousStructs{})
+}
+
+func parseType(gofile *ast.File, expr ast.Expr, sel *ast.Ident, anon anonymousStructs) (*schema, error) {
 	p := schema{}
 	switch ftpe := expr.(type) {
 	case *ast.Ident: // simple value
@@ -98,7 +157,7 @@ func parseNamedType(gofile *ast.File, expr ast.Expr, sel *ast.Ident) (*schema, e
 		p.Format = format
 		return &p, nil
 	case *ast.StarExpr: // pointer to something, optional by default
-		t, err := parseNamedType(gofile, ftpe.X, sel)
+		t, err := parseType(gofile, ftpe.X, sel, anon)
 		if err != nil {
 			return nil, err
 		}
@@ -108,62 +167,58 @@ func parseNamedType(gofile *ast.File, expr ast.Expr, sel *ast.Ident) (*schema, e
 			t.Nullable = &tBool
 		}
 		return t, nil
-	case *ast.ArrayType: // slice type
-		cp, err := parseNamedType(gofile, ftpe.Elt, sel)
+	case *ast.ArrayType: // slice or array type
+		cp, err := parseType(gofile, ftpe.Elt, sel, anon)
 		if err != nil {
 			return nil, err
 		}
 
-		if cp.Format == "binary" {
+		if ftpe.Len == nil && cp.Format == "binary" {
 			p.Type = "string"
 			p.Format = "binary"
 			return &p, nil
 		}
 		p.Type = "array"
-		p.Items = map[string]interface{}{}
-		if cp.Type != "" {
-			p.Items["type"] = cp.Type
-			if len(cp.Items) != 0 {
-				p.Items["items"] = cp.Items
+		p.Items = cp
+
+		// fixed size array, encoding/json encodes a [16]byte as 16 numbers
+		if lit, ok := ftpe.Len.(*ast.BasicLit); ok && lit.Kind == token.INT {
+			if isByte(ftpe.Elt) {
+				p.Items = &schema{Type: "integer"}
 			}
-			if len(cp.Properties) != 0 {
-				p.Items["properties"] = cp.Properties
+			if l, err := strconv.ParseInt(lit.Value, 0, 64); err == nil {
+				size := int(l)
+				p.MinItems = &size
+				p.MaxItems = &size
 			}
 		}
-		if cp.Ref != "" {
-			p.Items["$ref"] = cp.Ref
-		}
 		return &p, nil
 	case *ast.StructType:
-		p = newEntity()
-		p.Type = "object"
-
-		for _, field := range ftpe.Fields.List {
-			j, err := parseJSONTag(ftpe.Fields.List[0])
-			if err != nil {
-				return nil, err
-			}
-
-			pnt, err := parseNamedType(gofile, field.Type, nil)
-			if err != nil {
-				return nil, err
-			}
-
-			p.Properties[j.name] = pnt
-
+		entity, errs := parseStructType(gofile, ftpe, anon)
+		if len(errs) > 0 {
+			return nil, errs[0]
 		}
 
-		return &p, nil
+		if anon.hoist != nil {
+			return anon.hoist(anon.name, entity), nil
+		}
+		return inlineSchema(entity), nil
 	case *ast.SelectorExpr:
-		t, err := parseNamedType(gofile, ftpe.X, ftpe.Sel)
+		x := ftpe.X
+		// the qualifier of a type argument of a generic type is an import
+		// path, see qualifyTypeExpr
+		if id, ok := x.(*ast.Ident); ok && strings.Contains(id.Name, "/") {
+			x = ast.NewIdent(packageName(id.Name))
+		}
+		t, err := parseType(gofile, x, ftpe.Sel, anon)
 		if err != nil {
 			return nil, err
 		}
 
 		return t, nil
 	case *ast.MapType:
-		k, kerr := parseNamedType(gofile, ftpe.Key, sel)
-		v, verr := parseNamedType(gofile, ftpe.Value, sel)
+		k, kerr := parseType(gofile, ftpe.Key, sel, anon)
+		v, verr := parseType(gofile, ftpe.Value, sel, anon)
 		if kerr != nil || verr != nil || k.Type != "string" {
 			// keys can only be of type string
 			return nil, fmt.Errorf("expr (%s) not yet unsupported", expr)
@@ -176,11 +231,25 @@ func parseNamedType(gofile *ast.File, expr ast.Expr, sel *ast.Ident) (*schema, e
 	case *ast.InterfaceType:
 		p.Ref = "#/components/schemas/AnyValue"
 		return &p, nil
+	case *ast.IndexExpr, *ast.IndexListExpr: // instantiated generic type
+		name, err := typeExprString(ftpe)
+		if err != nil {
+			return nil, err
+		}
+		p.Ref = schemaRefPrefix + name
+		p.metadata.RealName = name
+		return &p, nil
 	default:
 		return nil, fmt.Errorf("expr (%s) type (%s) is unsupported for a schema", ftpe, expr)
 	}
 }
 
+// isByte tells if expr is the byte or uint8 type
+func isByte(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && (ident.Name == "byte" || ident.Name == "uint8")
+}
+
 // https://swagger.io/specification/#dataTypes
 func parseIdentProperty(expr *ast.Ident) (t, format string, err error) {
 	switch expr.Name {
@@ -83,6 +83,7 @@ func TestParseFile(t *testing.T) {
 
 func TestParseNamedType(t *testing.T) {
 	tBool := true
+	four := 4
 	testCases := []parseNamedTypeTestCase{
 		{
 			description:    "Should parse *ast.Ident with unknown name",
@@ -113,8 +114,8 @@ func TestParseNamedType(t *testing.T) {
 		{
 			description: "Should parse *ast.ArrayType with known type",
 			expr:        &ast.ArrayType{Elt: &ast.Ident{Name: "time"}},
-			expectedSchema: &schema{Type: "array", Items: map[string]interface{}{
-				"type": "string",
+			expectedSchema: &schema{Type: "array", Items: &schema{
+				Type: "string", Format: "date-time",
 			}},
 		},
 		{
@@ -125,20 +126,60 @@ func TestParseNamedType(t *testing.T) {
 		{
 			description: "Should parse *ast.ArrayType with unknown type",
 			expr:        &ast.ArrayType{Elt: &ast.Ident{Name: "unknown"}},
-			expectedSchema: &schema{Type: "array", Items: map[string]interface{}{
-				"$ref": "#/components/schemas/unknown",
+			expectedSchema: &schema{Type: "array", Items: &schema{
+				Ref: "#/components/schemas/unknown",
 			}},
 		},
 		{
 			description: "Should parse *ast.ArrayType with array type",
 			expr:        &ast.ArrayType{Elt: &ast.ArrayType{Elt: &ast.Ident{Name: "float64"}}},
-			expectedSchema: &schema{Type: "array", Items: map[string]interface{}{
-				"type": "array",
-				"items": map[string]interface{}{
-					"type": "number",
+			expectedSchema: &schema{Type: "array", Items: &schema{
+				Type: "array",
+				Items: &schema{
+					Type: "number",
+				},
+			}},
+		},
+		{
+			description: "Should parse *ast.ArrayType with fixed size",
+			expr:        &ast.ArrayType{Len: &ast.BasicLit{Kind: token.INT, Value: "4"}, Elt: &ast.Ident{Name: "string"}},
+			expectedSchema: &schema{Type: "array", MinItems: &four, MaxItems: &four, Items: &schema{
+				Type: "string",
+			}},
+		},
+		{
+			description: "Should parse *ast.ArrayType of bytes with fixed size as numbers",
+			expr:        &ast.ArrayType{Len: &ast.BasicLit{Kind: token.INT, Value: "4"}, Elt: &ast.Ident{Name: "byte"}},
+			expectedSchema: &schema{Type: "array", MinItems: &four, MaxItems: &four, Items: &schema{
+				Type: "integer",
+			}},
+		},
+		{
+			description: "Should parse *ast.ArrayType of maps of pointers",
+			expr: &ast.ArrayType{Elt: &ast.MapType{
+				Key:   &ast.Ident{Name: "string"},
+				Value: &ast.StarExpr{X: &ast.Ident{Name: "int64"}},
+			}},
+			expectedSchema: &schema{Type: "array", Items: &schema{
+				Type: "object",
+				AdditionalProperties: &schema{
+					Type: "integer", Format: "int64", Nullable: &tBool,
 				},
 			}},
 		},
+		{
+			description: "Should parse *ast.MapType of arrays",
+			expr: &ast.MapType{
+				Key:   &ast.Ident{Name: "string"},
+				Value: &ast.ArrayType{Elt: &ast.SelectorExpr{X: &ast.Ident{Name: "time"}, Sel: &ast.Ident{Name: "Time"}}},
+			},
+			expectedSchema: &schema{
+				Type: "object",
+				AdditionalProperties: &schema{Type: "array", Items: &schema{
+					Type: "string", Format: "date-time",
+				}},
+			},
+		},
 		{
 			description: "Should *ast.ArrayType with *ast.StructType",
 			expr: &ast.ArrayType{
@@ -146,8 +187,9 @@ func TestParseNamedType(t *testing.T) {
 					Fields: &ast.FieldList{
 						List: []*ast.Field{
 							{
-								Type: &ast.Ident{Name: "string"},
-								Tag:  &ast.BasicLit{Value: "`json:\"str\"`"},
+								Names: []*ast.Ident{{Name: "Str"}},
+								Type:  &ast.Ident{Name: "string"},
+								Tag:   &ast.BasicLit{Value: "`json:\"str\"`"},
 							},
 						},
 					},
@@ -155,9 +197,9 @@ func TestParseNamedType(t *testing.T) {
 			},
 			expectedSchema: &schema{
 				Type: "array",
-				Items: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]*schema{
+				Items: &schema{
+					Type: "object",
+					Properties: map[string]*schema{
 						"str": {
 							Type: "string",
 						},
@@ -171,8 +213,9 @@ func TestParseNamedType(t *testing.T) {
 				Fields: &ast.FieldList{
 					List: []*ast.Field{
 						{
-							Type: &ast.Ident{Name: "string"},
-							Tag:  &ast.BasicLit{Value: "`json:\"str\"`"},
+							Names: []*ast.Ident{{Name: "Str"}},
+							Type:  &ast.Ident{Name: "string"},
+							Tag:   &ast.BasicLit{Value: "`json:\"str\"`"},
 						},
 					},
 				},
@@ -260,21 +303,40 @@ func TestParseNamedType(t *testing.T) {
 		{
 			description: "Should parse correctly a selector of an array of pointer of unknown type",
 			expr:        &ast.SelectorExpr{X: &ast.ArrayType{Elt: &ast.StarExpr{X: &ast.Ident{Name: "unknown"}}}},
-			expectedSchema: &schema{Type: "array", Items: map[string]interface{}{
-				"$ref": "#/components/schemas/unknown",
+			expectedSchema: &schema{Type: "array", Items: &schema{
+				Ref: "#/components/schemas/unknown",
 			}},
 		},
 		{
 			description: "Should parse correctly a selector of an array of pointer of time type",
 			expr:        &ast.SelectorExpr{X: &ast.ArrayType{Elt: &ast.StarExpr{X: &ast.Ident{Name: "time"}}}},
-			expectedSchema: &schema{Type: "array", Items: map[string]interface{}{
-				"type": "string",
+			expectedSchema: &schema{Type: "array", Items: &schema{
+				Type: "string", Format: "date-time", Nullable: &tBool,
 			}},
 		},
+		{
+			description: "Should parse *ast.IndexExpr as a generic type instance",
+			expr: &ast.IndexExpr{
+				X:     &ast.Ident{Name: "Page"},
+				Index: &ast.SelectorExpr{X: &ast.Ident{Name: "otherpackage"}, Sel: &ast.Ident{Name: "Data"}},
+			},
+			expectedSchema: &schema{Ref: "#/components/schemas/Page[otherpackage.Data]"},
+		},
+		{
+			description: "Should parse *ast.IndexListExpr as a generic type instance",
+			expr: &ast.IndexListExpr{
+				X: &ast.Ident{Name: "Pair"},
+				Indices: []ast.Expr{
+					&ast.Ident{Name: "string"},
+					&ast.ArrayType{Elt: &ast.StarExpr{X: &ast.Ident{Name: "Pet"}}},
+				},
+			},
+			expectedSchema: &schema{Ref: "#/components/schemas/Pair[string,[]*Pet]"},
+		},
 		{
 			description:   "Should return  error for unsupported types",
 			expr:          &ast.FuncType{},
-			expectedError: "expr (&{%!s(token.Pos=0) %!s(*ast.FieldList=<nil>) %!s(*ast.FieldList=<nil>)}) type (&{%!s(token.Pos=0) %!s(*ast.FieldList=<nil>) %!s(*ast.FieldList=<nil>)}) is unsupported for a schema",
+			expectedError: "expr (&{%!s(token.Pos=0) %!s(*ast.FieldList=<nil>) %!s(*ast.FieldList=<nil>) %!s(*ast.FieldList=<nil>)}) type (&{%!s(token.Pos=0) %!s(*ast.FieldList=<nil>) %!s(*ast.FieldList=<nil>) %!s(*ast.FieldList=<nil>)}) is unsupported for a schema",
 		},
 	}
 	for _, tc := range testCases {
@@ -0,0 +1,197 @@
+package docparser
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// verbs are the operations of a path item, in the order of the specification
+var verbs = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// paths are the path items by url, or by expression for a callback. The
+// extensions and the $ref of a callback are kept as they are.
+type paths map[string]path
+
+// path is a path item, its operations are by verb
+type path struct {
+	Ref         string      `yaml:"$ref,omitempty"`
+	Summary     string      `yaml:"summary,omitempty"`
+	Description string      `yaml:"description,omitempty"`
+	Servers     []server    `yaml:"servers,omitempty"`
+	Parameters  []parameter `yaml:"parameters,omitempty"`
+	Extensions  extensions  `yaml:",inline"`
+
+	Operations map[string]operation `yaml:"-"`
+
+	// raw is the value of an extension or a $ref found among the paths
+	raw   interface{}
+	isRaw bool
+}
+
+func (ps *paths) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	items := yaml.MapSlice{}
+	if err := unmarshal(&items); err != nil {
+		return err
+	}
+
+	*ps = make(paths, len(items))
+	for _, item := range items {
+		key := fmt.Sprint(item.Key)
+		if strings.HasPrefix(key, "x-") || key == "$ref" {
+			(*ps)[key] = path{raw: item.Value, isRaw: true}
+			continue
+		}
+
+		// the value is decoded again as a path item
+		b, err := yaml.Marshal(item.Value)
+		if err != nil {
+			return err
+		}
+		p := path{}
+		if err := yaml.Unmarshal(b, &p); err != nil {
+			return fmt.Errorf("path %s: %w", key, err)
+		}
+		(*ps)[key] = p
+	}
+	return nil
+}
+
+func (p *path) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain path
+	if err := unmarshal((*plain)(p)); err != nil {
+		return err
+	}
+
+	ops := struct {
+		Get     *operation
+		Put     *operation
+		Post    *operation
+		Delete  *operation
+		Options *operation
+		Head    *operation
+		Patch   *operation
+		Trace   *operation
+	}{}
+	if err := unmarshal(&ops); err != nil {
+		return err
+	}
+
+	p.Operations = make(map[string]operation)
+	for verb, op := range map[string]*operation{
+		"get": ops.Get, "put": ops.Put, "post": ops.Post, "delete": ops.Delete,
+		"options": ops.Options, "head": ops.Head, "patch": ops.Patch, "trace": ops.Trace,
+	} {
+		// the operations are caught by the extensions
+		delete(p.Extensions, verb)
+		if op != nil {
+			p.Operations[verb] = *op
+		}
+	}
+	if len(p.Extensions) == 0 {
+		p.Extensions = nil
+	}
+	return nil
+}
+
+func (p path) MarshalYAML() (interface{}, error) {
+	if p.isRaw {
+		return p.raw, nil
+	}
+
+	item := yaml.MapSlice{}
+	add := func(key string, value interface{}, empty bool) {
+		if !empty {
+			item = append(item, yaml.MapItem{Key: key, Value: value})
+		}
+	}
+
+	add("$ref", p.Ref, p.Ref == "")
+	add("summary", p.Summary, p.Summary == "")
+	add("description", p.Description, p.Description == "")
+	for _, verb := range verbs {
+		op, ok := p.Operations[verb]
+		add(verb, op, !ok)
+	}
+	add("servers", p.Servers, len(p.Servers) == 0)
+	add("parameters", p.Parameters, len(p.Parameters) == 0)
+
+	keys := make([]string, 0, len(p.Extensions))
+	for k := range p.Extensions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		add(k, p.Extensions[k], false)
+	}
+	return item, nil
+}
+
+// mergeFields sets the fields of the path item which are still empty with the
+// ones of other, the operations aren't merged
+func (p *path) mergeFields(other path) {
+	if p.Operations == nil {
+		p.Operations = make(map[string]operation)
+	}
+	if p.Ref == "" {
+		p.Ref = other.Ref
+	}
+	if p.Summary == "" {
+		p.Summary = other.Summary
+	}
+	if p.Description == "" {
+		p.Description = other.Description
+	}
+	if len(p.Servers) == 0 {
+		p.Servers = other.Servers
+	}
+	if len(p.Parameters) == 0 {
+		p.Parameters = other.Parameters
+	}
+	for k, v := range other.Extensions {
+		if _, ok := p.Extensions[k]; ok {
+			continue
+		}
+		if p.Extensions == nil {
+			p.Extensions = make(extensions)
+		}
+		p.Extensions[k] = v
+	}
+}
+
+// walkOperations calls fn on the operations of the path items, by url then
+// verb, and on the ones of their callbacks. The location of an operation is
+// the one of the path items, key: url, after the location of the callback.
+func (ps paths) walkOperations(location, key string, fn func(location, verb string, op *operation)) {
+	for _, url := range sortedKeys(reflect.ValueOf(ps)) {
+		p := ps[url]
+		loc := key + ": " + url
+		if location != "" {
+			loc = location + ", " + loc
+		}
+		for _, verb := range verbs {
+			op, ok := p.Operations[verb]
+			if !ok {
+				continue
+			}
+			fn(loc, verb, &op)
+			for _, name := range sortedKeys(reflect.ValueOf(op.Callbacks)) {
+				op.Callbacks[name].walkOperations(fmt.Sprintf("%s, verb: %s, callback: %s", loc, verb, name), "url", fn)
+			}
+			p.Operations[verb] = op
+		}
+	}
+}
+
+// walkOperations calls fn on every operation of the document: the ones of the
+// paths, of the webhooks and of the callbacks
+func (spec *openAPI) walkOperations(fn func(location, verb string, op *operation)) {
+	spec.Paths.walkOperations("", "url", fn)
+	spec.Webhooks.walkOperations("", "webhook", fn)
+	for _, name := range sortedKeys(reflect.ValueOf(spec.Components.Callbacks)) {
+		spec.Components.Callbacks[name].walkOperations("callback: "+name, "url", fn)
+	}
+}
@@ -0,0 +1,96 @@
+package docparser
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// normalizedYAML returns a document with sorted keys and without the required
+// fields set to false, which is their default value. The required of the
+// parameters, the headers and the request bodies is omitted when false.
+func normalizedYAML(t *testing.T, b []byte) string {
+	var doc interface{}
+	assert.NoError(t, yaml.Unmarshal(b, &doc))
+
+	var normalize func(v interface{}) interface{}
+	normalize = func(v interface{}) interface{} {
+		switch n := v.(type) {
+		case map[interface{}]interface{}:
+			m := make(map[string]interface{}, len(n))
+			for k, e := range n {
+				if required, ok := e.(bool); ok && k == "required" && !required {
+					continue
+				}
+				m[fmt.Sprint(k)] = normalize(e)
+			}
+			return m
+		case []interface{}:
+			for i, e := range n {
+				n[i] = normalize(e)
+			}
+			return n
+		}
+		return v
+	}
+
+	out, err := yaml.Marshal(normalize(doc))
+	assert.NoError(t, err)
+	return string(out)
+}
+
+func TestRoundTrip(t *testing.T) {
+	files, err := filepath.Glob("testdata/roundtrip/*.yaml")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, files)
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			src, err := ioutil.ReadFile(file)
+			assert.NoError(t, err)
+
+			spec := NewOpenAPI()
+			assert.NoError(t, yaml.Unmarshal(src, &spec))
+
+			// merging a document into itself doesn't change it
+			other := NewOpenAPI()
+			assert.NoError(t, yaml.Unmarshal(src, &other))
+			assert.NoError(t, spec.Merge(other))
+
+			out, err := yaml.Marshal(&spec)
+			assert.NoError(t, err)
+			assert.Equal(t, normalizedYAML(t, src), normalizedYAML(t, out))
+		})
+	}
+}
+
+func TestRoundTripExplicitValues(t *testing.T) {
+	src, err := ioutil.ReadFile("testdata/roundtrip/features.yaml")
+	assert.NoError(t, err)
+
+	spec := NewOpenAPI()
+	assert.NoError(t, yaml.Unmarshal(src, &spec))
+
+	// an empty security removes the security of the document
+	security := spec.Paths["/health"].Operations["get"].Security
+	if assert.NotNil(t, security) {
+		assert.Empty(t, *security)
+	}
+
+	explode := spec.Paths["/pets/{petId}"].Operations["get"].Parameters[0].Explode
+	if assert.NotNil(t, explode) {
+		assert.False(t, *explode)
+	}
+
+	b, err := yaml.Marshal(spec.Components.Schemas["Pet"])
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "additionalProperties: false")
+
+	b, err = yaml.Marshal(spec.Paths["/pets/{petId}"].Operations["get"].Parameters[1])
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "additionalProperties: false")
+}
@@ -0,0 +1,251 @@
+package docparser
+
+import (
+	"math"
+	"reflect"
+	"strings"
+)
+
+// formatSamples are the values synthesised for the string formats
+var formatSamples = map[string]string{
+	"date-time": "2006-01-02T15:04:05Z",
+	"date":      "2006-01-02",
+	"time":      "15:04:05",
+	"uuid":      "3fa85f64-5717-4562-b3fc-2c963f66afa6",
+	"email":     "user@example.com",
+	"uri":       "https://example.com",
+	"url":       "https://example.com",
+	"hostname":  "example.com",
+	"ipv4":      "192.0.2.1",
+	"ipv6":      "2001:db8::1",
+	"byte":      "c3RyaW5n",
+	"password":  "password",
+}
+
+// SetFillExamples fills the examples of the document once parsed, see
+// FillExamples
+func (spec *openAPI) SetFillExamples(fill bool) {
+	spec.fillExamples = fill
+}
+
+// FillExamples sets the example of every component schema, and of every media
+// type of the responses and the request bodies, which has none. The example
+// is composed of the examples of the properties, following the references,
+// and the other values are synthesised from the schemas.
+func (spec *openAPI) FillExamples() error {
+	schemas, err := spec.componentSchemas()
+	if err != nil {
+		return err
+	}
+	sm := sampler{schemas: schemas}
+
+	for _, name := range sortedKeys(reflect.ValueOf(spec.Components.Schemas)) {
+		// the schema is sampled by reference, for its recursive properties
+		// to be left out
+		ref := &schema{Ref: "#/components/schemas/" + name}
+		switch s := spec.Components.Schemas[name].(type) {
+		case *schema:
+			if s.Example == nil {
+				s.Example = sm.sample(ref)
+			}
+		case *composedSchema:
+			if s.Example == nil {
+				s.Example = sm.sample(ref)
+			}
+		default:
+			// the schemas of a document read from a file are maps
+			if sch := schemas[name]; sch.Example == nil {
+				if sch.Example = sm.sample(ref); sch.Example != nil {
+					spec.Components.Schemas[name] = sch
+				}
+			}
+		}
+	}
+
+	for _, ps := range []paths{spec.Paths, spec.Webhooks} {
+		for _, p := range ps {
+			for _, op := range p.Operations {
+				sm.fillContents(op.RequestBody.Content)
+				for _, resp := range op.Responses {
+					sm.fillContents(resp.Content)
+				}
+			}
+		}
+	}
+	for _, body := range spec.Components.RequestBodies {
+		sm.fillContents(body.Content)
+	}
+	for _, resp := range spec.Components.Responses {
+		sm.fillContents(resp.Content)
+	}
+	return nil
+}
+
+// fillContents sets the example of the media types which have none
+func (sm sampler) fillContents(c map[string]content) {
+	for t, media := range c {
+		if media.Example != nil || len(media.Examples) > 0 || media.Schema == nil {
+			continue
+		}
+		if media.Example = sm.sample(media.Schema); media.Example != nil {
+			c[t] = media
+		}
+	}
+}
+
+// sampler synthesises the values of the schemas, the references are
+// followed in the component schemas
+type sampler struct {
+	schemas map[string]*schema
+}
+
+// sample returns a value of the schema, its example or default when it has
+// one, or a value respecting its enum, format and bounds
+func (sm sampler) sample(s *schema) interface{} {
+	return sm.value(s, make(map[string]bool))
+}
+
+// value returns a value of the schema, visiting are the references being
+// synthesised, a recursive reference is left empty
+func (sm sampler) value(s *schema, visiting map[string]bool) interface{} {
+	if s == nil || s.boolean != nil {
+		return nil
+	}
+	if s.Ref != "" {
+		name := refName(s.Ref)
+		if visiting[name] {
+			return nil
+		}
+		visiting[name] = true
+		defer delete(visiting, name)
+		return sm.value(sm.schemas[name], visiting)
+	}
+	switch {
+	case s.Example != nil:
+		return s.Example
+	case s.Default != nil:
+		return s.Default
+	case len(s.Enum) > 0:
+		return s.Enum[0]
+	case len(s.AllOf) > 0:
+		return sm.allOf(s, visiting)
+	case len(s.OneOf) > 0:
+		return sm.value(s.OneOf[0], visiting)
+	case len(s.AnyOf) > 0:
+		return sm.value(s.AnyOf[0], visiting)
+	}
+
+	switch s.Type {
+	case "string":
+		return sampleString(s)
+	case "integer":
+		return int64(sampleNumber(s, 1))
+	case "number":
+		return sampleNumber(s, 0.5)
+	case "boolean":
+		return true
+	case "array":
+		n := 1
+		if s.MinItems != nil && *s.MinItems > n {
+			n = *s.MinItems
+		}
+		if s.MaxItems != nil && *s.MaxItems < n {
+			n = *s.MaxItems
+		}
+		items := make([]interface{}, 0, n)
+		if item := sm.value(s.Items, visiting); item != nil || isNullable(s.Items) {
+			for i := 0; i < n; i++ {
+				items = append(items, item)
+			}
+		}
+		return items
+	case "object", "":
+		if s.Type == "" && len(s.Properties) == 0 && s.AdditionalProperties == nil {
+			return nil
+		}
+		return sm.object(s, visiting)
+	}
+	return nil
+}
+
+// object returns the value of an object schema, a map has a single key. The
+// recursive properties and the ones of any type are left out.
+func (sm sampler) object(s *schema, visiting map[string]bool) map[string]interface{} {
+	obj := make(map[string]interface{}, len(s.Properties))
+	for name, p := range s.Properties {
+		if v := sm.value(p, visiting); v != nil || isNullable(p) {
+			obj[name] = v
+		}
+	}
+	if len(s.Properties) == 0 && s.AdditionalProperties != nil && s.AdditionalProperties.boolean == nil {
+		if v := sm.value(s.AdditionalProperties, visiting); v != nil || isNullable(s.AdditionalProperties) {
+			obj["key"] = v
+		}
+	}
+	return obj
+}
+
+func isNullable(s *schema) bool {
+	return s != nil && s.Nullable != nil && *s.Nullable
+}
+
+// allOf returns the value of the composed schemas, their objects are merged
+func (sm sampler) allOf(s *schema, visiting map[string]bool) interface{} {
+	var value interface{}
+	merged := map[string]interface{}{}
+	for _, part := range s.AllOf {
+		v := sm.value(part, visiting)
+		if obj, ok := v.(map[string]interface{}); ok {
+			for k, pv := range obj {
+				merged[k] = pv
+			}
+			value = merged
+		} else if v != nil && value == nil {
+			value = v
+		}
+	}
+	// the schema may declare properties next to allOf
+	if len(s.Properties) > 0 {
+		for k, pv := range sm.object(s, visiting) {
+			merged[k] = pv
+		}
+		value = merged
+	}
+	return value
+}
+
+func sampleString(s *schema) string {
+	if v, ok := formatSamples[s.Format]; ok {
+		return v
+	}
+	v := "string"
+	if s.MinLength != nil && len(v) < *s.MinLength {
+		v += strings.Repeat("x", *s.MinLength-len(v))
+	}
+	if s.MaxLength != nil && len(v) > *s.MaxLength {
+		v = v[:*s.MaxLength]
+	}
+	return v
+}
+
+// sampleNumber returns 0, or the closest number within the bounds, step is
+// the distance to an exclusive bound
+func sampleNumber(s *schema, step float64) float64 {
+	v := 0.0
+	switch {
+	case s.Minimum != nil && (v < *s.Minimum || (s.ExclusiveMinimum && v <= *s.Minimum)):
+		v = *s.Minimum
+		if s.ExclusiveMinimum {
+			v += step
+		}
+	case s.Maximum != nil && (v > *s.Maximum || (s.ExclusiveMaximum && v >= *s.Maximum)):
+		v = *s.Maximum
+		if s.ExclusiveMaximum {
+			v -= step
+		}
+	}
+	if s.MultipleOf != nil && *s.MultipleOf > 0 {
+		v = math.Ceil(v / *s.MultipleOf) * *s.MultipleOf
+	}
+	return v
+}
@@ -0,0 +1,140 @@
+package docparser
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestSample(t *testing.T) {
+	schemas := map[string]*schema{}
+	assert.NoError(t, yaml.Unmarshal([]byte(`
+Node:
+  type: object
+  properties:
+    name:
+      type: string
+      example: root
+    children:
+      type: array
+      items:
+        $ref: '#/components/schemas/Node'
+    parent:
+      $ref: '#/components/schemas/Node'
+Named:
+  type: object
+  properties:
+    name:
+      type: string
+`), &schemas))
+	sm := sampler{schemas: schemas}
+
+	tests := []struct {
+		name     string
+		schema   string
+		expected interface{}
+	}{
+		{name: "string", schema: `{type: string}`, expected: "string"},
+		{name: "date-time", schema: `{type: string, format: date-time}`, expected: "2006-01-02T15:04:05Z"},
+		{name: "uuid", schema: `{type: string, format: uuid}`, expected: "3fa85f64-5717-4562-b3fc-2c963f66afa6"},
+		{name: "email", schema: `{type: string, format: email}`, expected: "user@example.com"},
+		{name: "min length", schema: `{type: string, minLength: 8}`, expected: "stringxx"},
+		{name: "max length", schema: `{type: string, maxLength: 3}`, expected: "str"},
+		{name: "enum", schema: `{type: string, enum: [b, a]}`, expected: "b"},
+		{name: "default", schema: `{type: integer, default: 3}`, expected: 3},
+		{name: "minimum", schema: `{type: integer, minimum: 5}`, expected: int64(5)},
+		{name: "exclusive minimum", schema: `{type: integer, minimum: 5, exclusiveMinimum: true}`, expected: int64(6)},
+		{name: "maximum", schema: `{type: number, maximum: -2}`, expected: -2.0},
+		{name: "multiple of", schema: `{type: integer, minimum: 5, multipleOf: 4}`, expected: int64(8)},
+		{name: "boolean", schema: `{type: boolean}`, expected: true},
+		{name: "array", schema: `{type: array, minItems: 2, items: {type: integer}}`, expected: []interface{}{int64(0), int64(0)}},
+		{name: "map", schema: `{type: object, additionalProperties: {type: string, format: email}}`, expected: map[string]interface{}{"key": "user@example.com"}},
+		{name: "any", schema: `{description: anything}`, expected: nil},
+		{
+			name:     "recursive",
+			schema:   `{$ref: '#/components/schemas/Node'}`,
+			expected: map[string]interface{}{"name": "root", "children": []interface{}{}},
+		},
+		{
+			name:     "allOf",
+			schema:   `{allOf: [{$ref: '#/components/schemas/Named'}, {type: object, properties: {age: {type: integer, minimum: 1}}}]}`,
+			expected: map[string]interface{}{"name": "string", "age": int64(1)},
+		},
+		{name: "oneOf", schema: `{oneOf: [{type: boolean}, {type: string}]}`, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &schema{}
+			assert.NoError(t, yaml.Unmarshal([]byte(tt.schema), s))
+			assert.Equal(t, tt.expected, sm.sample(s))
+		})
+	}
+}
+
+func TestFillExamples(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, map[string]string{
+		filepath.Join(dir, "pets.go"): `package pets
+
+import "time"
+
+// @openapi:schema
+type Pet struct {
+	// @openapi:example Rex
+	Name     string            ` + "`json:\"name\"`" + `
+	Born     time.Time         ` + "`json:\"born\"`" + `
+	Tags     []string          ` + "`json:\"tags\"`" + `
+	Owner    *Owner            ` + "`json:\"owner\"`" + `
+	Children map[string]*Pet   ` + "`json:\"children\"`" + `
+}
+
+// @openapi:schema
+type Owner struct {
+	// @openapi:example rex@example.com
+	Email string ` + "`json:\"email\"`" + `
+}
+
+// @openapi:path
+// /pets:
+//   post:
+//     requestBody:
+//       content:
+//         application/json:
+//           schema:
+//             $ref: "#/components/schemas/Pet"
+//     responses:
+//       201:
+//         description: The pet
+//         content:
+//           application/json:
+//             schema:
+//               $ref: "#/components/schemas/Pet"
+//             example:
+//               name: Felix
+func CreatePet() {}
+`,
+	})
+
+	spec := NewOpenAPI()
+	spec.SetFillExamples(true)
+	spec.Parse([]string{dir}, nil, "vendor", false)
+	assert.Empty(t, spec.Errors())
+
+	pet := map[string]interface{}{
+		"name":     "Rex",
+		"born":     "2006-01-02T15:04:05Z",
+		"tags":     []interface{}{"string"},
+		"owner":    map[string]interface{}{"email": "rex@example.com"},
+		"children": map[string]interface{}{},
+	}
+	assert.Equal(t, pet, spec.Components.Schemas["Pet"].(*schema).Example)
+	assert.Equal(t, map[string]interface{}{"email": "rex@example.com"}, spec.Components.Schemas["Owner"].(*schema).Example)
+
+	op := spec.Paths["/pets"].Operations["post"]
+	assert.Equal(t, pet, op.RequestBody.Content["application/json"].Example)
+	// the examples given are kept
+	assert.Equal(t, map[interface{}]interface{}{"name": "Felix"}, op.Responses["201"].Content["application/json"].Example)
+}
@@ -0,0 +1,285 @@
+package docparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+var regexpUUID = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// validator validates the values against the schemas, the references are
+// followed in the component schemas
+type validator struct {
+	schemas map[string]*schema
+}
+
+// validate returns the reasons why the value doesn't match the schema, at is
+// the location of the value, i.e. body.pets[0].name
+func (v validator) validate(s *schema, value interface{}, at string) []string {
+	return v.check(s, normalizeValue(value), at, 0)
+}
+
+func (v validator) check(s *schema, value interface{}, at string, depth int) []string {
+	if s == nil || depth > 32 {
+		return nil
+	}
+	if s.boolean != nil {
+		if !*s.boolean {
+			return []string{fmt.Sprintf("%s isn't allowed", at)}
+		}
+		return nil
+	}
+	if s.Ref != "" {
+		return v.check(v.schemas[refName(s.Ref)], value, at, depth+1)
+	}
+	if value == nil {
+		if s.Nullable != nil && *s.Nullable || s.Type == "" {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s must not be null", at)}
+	}
+
+	var errs []string
+	for _, part := range s.AllOf {
+		errs = append(errs, v.check(part, value, at, depth+1)...)
+	}
+	if len(s.OneOf) > 0 {
+		switch n := v.matchCount(s.OneOf, value, at, depth); {
+		case n == 0:
+			errs = append(errs, fmt.Sprintf("%s doesn't match any schema of oneOf", at))
+		case n > 1:
+			errs = append(errs, fmt.Sprintf("%s matches %d schemas of oneOf, only one is allowed", at, n))
+		}
+	}
+	if len(s.AnyOf) > 0 && !v.matchesAny(s.AnyOf, value, at, depth) {
+		errs = append(errs, fmt.Sprintf("%s doesn't match any schema of anyOf", at))
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		errs = append(errs, fmt.Sprintf("%s must be one of %s, got %s", at, compactJSON(s.Enum), compactJSON(value)))
+	}
+
+	if s.Type != "" && !hasType(s.Type, value) {
+		return append(errs, fmt.Sprintf("%s must be %s %s, got %s", at, article(s.Type), s.Type, compactJSON(value)))
+	}
+	switch value := value.(type) {
+	case float64:
+		errs = append(errs, checkNumber(s, value, at)...)
+	case string:
+		errs = append(errs, checkString(s, value, at)...)
+	case []interface{}:
+		if s.MinItems != nil && len(value) < *s.MinItems {
+			errs = append(errs, fmt.Sprintf("%s must have at least %d items", at, *s.MinItems))
+		}
+		if s.MaxItems != nil && len(value) > *s.MaxItems {
+			errs = append(errs, fmt.Sprintf("%s must have at most %d items", at, *s.MaxItems))
+		}
+		if s.UniqueItems && !uniqueItems(value) {
+			errs = append(errs, fmt.Sprintf("%s must have unique items", at))
+		}
+		for i, item := range value {
+			errs = append(errs, v.check(s.Items, item, fmt.Sprintf("%s[%d]", at, i), depth+1)...)
+		}
+	case map[string]interface{}:
+		errs = append(errs, v.checkObject(s, value, at, depth)...)
+	}
+	return errs
+}
+
+func (v validator) checkObject(s *schema, value map[string]interface{}, at string, depth int) []string {
+	var errs []string
+	for _, name := range s.Required {
+		if _, ok := value[name]; !ok {
+			errs = append(errs, fmt.Sprintf("%s.%s is required", at, name))
+		}
+	}
+	if s.MinProperties != nil && len(value) < *s.MinProperties {
+		errs = append(errs, fmt.Sprintf("%s must have at least %d properties", at, *s.MinProperties))
+	}
+	if s.MaxProperties != nil && len(value) > *s.MaxProperties {
+		errs = append(errs, fmt.Sprintf("%s must have at most %d properties", at, *s.MaxProperties))
+	}
+
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if p, ok := s.Properties[name]; ok {
+			errs = append(errs, v.check(p, value[name], at+"."+name, depth+1)...)
+		} else if s.AdditionalProperties != nil {
+			errs = append(errs, v.check(s.AdditionalProperties, value[name], at+"."+name, depth+1)...)
+		}
+	}
+	return errs
+}
+
+// matchesAny tells if the value matches one of the schemas at least, for anyOf
+func (v validator) matchesAny(schemas []*schema, value interface{}, at string, depth int) bool {
+	for _, s := range schemas {
+		if len(v.check(s, value, at, depth+1)) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// matchCount returns the number of schemas matched by the value, oneOf
+// requires exactly one
+func (v validator) matchCount(schemas []*schema, value interface{}, at string, depth int) int {
+	n := 0
+	for _, s := range schemas {
+		if len(v.check(s, value, at, depth+1)) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func checkNumber(s *schema, value float64, at string) []string {
+	var errs []string
+	if s.Minimum != nil && (value < *s.Minimum || s.ExclusiveMinimum && value == *s.Minimum) {
+		op := ">="
+		if s.ExclusiveMinimum {
+			op = ">"
+		}
+		errs = append(errs, fmt.Sprintf("%s must be %s %v", at, op, *s.Minimum))
+	}
+	if s.Maximum != nil && (value > *s.Maximum || s.ExclusiveMaximum && value == *s.Maximum) {
+		op := "<="
+		if s.ExclusiveMaximum {
+			op = "<"
+		}
+		errs = append(errs, fmt.Sprintf("%s must be %s %v", at, op, *s.Maximum))
+	}
+	if s.MultipleOf != nil && *s.MultipleOf > 0 {
+		if q := value / *s.MultipleOf; math.Abs(q-math.Round(q)) > 1e-9 {
+			errs = append(errs, fmt.Sprintf("%s must be a multiple of %v", at, *s.MultipleOf))
+		}
+	}
+	return errs
+}
+
+func checkString(s *schema, value string, at string) []string {
+	var errs []string
+	n := utf8.RuneCountInString(value)
+	if s.MinLength != nil && n < *s.MinLength {
+		errs = append(errs, fmt.Sprintf("%s must have at least %d characters", at, *s.MinLength))
+	}
+	if s.MaxLength != nil && n > *s.MaxLength {
+		errs = append(errs, fmt.Sprintf("%s must have at most %d characters", at, *s.MaxLength))
+	}
+	if s.Pattern != "" {
+		if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(value) {
+			errs = append(errs, fmt.Sprintf("%s must match %s", at, s.Pattern))
+		}
+	}
+	if !hasFormat(s.Format, value) {
+		errs = append(errs, fmt.Sprintf("%s must be a valid %s, got %q", at, s.Format, value))
+	}
+	return errs
+}
+
+// hasFormat checks the formats which are checked, the others are accepted
+func hasFormat(format, value string) bool {
+	var err error
+	switch format {
+	case "date-time":
+		_, err = time.Parse(time.RFC3339, value)
+	case "date":
+		_, err = time.Parse("2006-01-02", value)
+	case "uuid":
+		return regexpUUID.MatchString(value)
+	case "email":
+		_, err = mail.ParseAddress(value)
+	}
+	return err == nil
+}
+
+func hasType(t string, value interface{}) bool {
+	switch value := value.(type) {
+	case string:
+		return t == "string"
+	case bool:
+		return t == "boolean"
+	case float64:
+		return t == "number" || t == "integer" && value == math.Trunc(value)
+	case []interface{}:
+		return t == "array"
+	case map[string]interface{}:
+		return t == "object"
+	}
+	return false
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, e := range enum {
+		if reflect.DeepEqual(normalizeValue(e), value) {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueItems(items []interface{}) bool {
+	for i := range items {
+		for j := i + 1; j < len(items); j++ {
+			if reflect.DeepEqual(items[i], items[j]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func article(t string) string {
+	if t == "integer" || t == "object" || t == "array" {
+		return "an"
+	}
+	return "a"
+}
+
+// normalizeValue returns the value as decoded from json: the maps decoded
+// from yaml have string keys and the numbers are float64
+func normalizeValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			m[fmt.Sprint(k)] = normalizeValue(value)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			m[k] = normalizeValue(value)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, value := range v {
+			l[i] = normalizeValue(value)
+		}
+		return l
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	}
+	return v
+}
@@ -0,0 +1,110 @@
+package docparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestValidate(t *testing.T) {
+	schemas := map[string]*schema{}
+	assert.NoError(t, yaml.Unmarshal([]byte(`
+Pet:
+  type: object
+  required: [name]
+  additionalProperties: false
+  properties:
+    name:
+      type: string
+      pattern: ^[A-Z]
+    age:
+      type: integer
+      minimum: 0
+      exclusiveMinimum: true
+    weight:
+      type: number
+      multipleOf: 0.5
+    email:
+      type: string
+      format: email
+    owner:
+      type: string
+      nullable: true
+    tags:
+      type: array
+      maxItems: 2
+      uniqueItems: true
+      items:
+        type: string
+    kind:
+      oneOf:
+        - type: string
+          enum: [cat, dog]
+        - type: integer
+    size:
+      oneOf:
+        - type: integer
+        - type: number
+`), &schemas))
+	v := validator{schemas: schemas}
+	pet := &schema{Ref: "#/components/schemas/Pet"}
+
+	tests := []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{
+			name:  "valid",
+			value: `{name: Rex, age: 3, weight: 4.5, email: rex@example.com, owner: null, tags: [a, b], kind: dog}`,
+		},
+		{
+			name:  "oneOf",
+			value: `{name: Rex, kind: 2, size: 2.5}`,
+		},
+		{
+			name:     "oneOf matched twice",
+			value:    `{name: Rex, size: 2}`,
+			expected: []string{"pet.size matches 2 schemas of oneOf, only one is allowed"},
+		},
+		{
+			name:     "required",
+			value:    `{age: 3}`,
+			expected: []string{"pet.name is required"},
+		},
+		{
+			name:     "types",
+			value:    `{name: 1, age: 1.5, owner: 2, tags: a}`,
+			expected: []string{"pet.age must be an integer, got 1.5", "pet.name must be a string, got 1", "pet.owner must be a string, got 2", `pet.tags must be an array, got "a"`},
+		},
+		{
+			name:     "bounds",
+			value:    `{name: rex, age: 0, weight: 1.2, email: rex, tags: [a, a, b]}`,
+			expected: []string{"pet.age must be > 0", `pet.email must be a valid email, got "rex"`, "pet.name must match ^[A-Z]", "pet.tags must have at most 2 items", "pet.tags must have unique items", "pet.weight must be a multiple of 0.5"},
+		},
+		{
+			name:     "enum",
+			value:    `{name: Rex, kind: bird}`,
+			expected: []string{"pet.kind doesn't match any schema of oneOf"},
+		},
+		{
+			name:     "additional properties",
+			value:    `{name: Rex, color: red}`,
+			expected: []string{"pet.color isn't allowed"},
+		},
+		{
+			name:     "null",
+			value:    `null`,
+			expected: []string{"pet must not be null"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			assert.NoError(t, yaml.Unmarshal([]byte(tt.value), &value))
+			assert.Equal(t, tt.expected, v.validate(pet, value, "pet"))
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package docparser
+
+import (
+	"go/ast"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fileResult is what is parsed in a file, the results are merged into the
+// document in the order of the files
+type fileResult struct {
+	path string
+	// spec is the document of the file, nil when the file is skipped
+	spec *openAPI
+	errs []error
+}
+
+// SetJobs sets the number of files parsed concurrently, the number of CPUs
+// when it's lower than 1
+func (spec *openAPI) SetJobs(jobs int) {
+	spec.jobs = jobs
+}
+
+// collectFiles returns the go files to parse in the folder root, in the order
+// of filepath.Walk
+func (spec *openAPI) collectFiles(root string, parseVendors []string) ([]string, error) {
+	files := []string{}
+	err := filepath.Walk(root, func(path string, f os.FileInfo, err error) error {
+		if f != nil && f.IsDir() {
+			if path != root && (skippedDir(f.Name()) || spec.excluded(root, path)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if validatePath(path, parseVendors) && spec.selected(root, path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// Dirs returns the folders of paths which aren't skipped by Parse, whether
+// they have go files or not, and the folders of their go.mod: the changes
+// in them can change the document
+func (spec *openAPI) Dirs(paths []string) []string {
+	dirs := []string{}
+	seen := make(map[string]bool)
+	add := func(dir string) {
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, root := range paths {
+		if goMod, err := findGoMod(root); err == nil {
+			add(filepath.Dir(goMod))
+		}
+		filepath.Walk(root, func(path string, f os.FileInfo, err error) error {
+			if f == nil || !f.IsDir() {
+				return nil
+			}
+			if path != root && (skippedDir(f.Name()) || spec.excluded(root, path)) {
+				return filepath.SkipDir
+			}
+			add(path)
+			return nil
+		})
+	}
+	return dirs
+}
+
+// fileSpec returns an empty document, with the options of spec, in which a
+// file is parsed
+func (spec *openAPI) fileSpec() *openAPI {
+	file := NewOpenAPI()
+	file.hoistAnonymousStructs = spec.hoistAnonymousStructs
+	file.defaultResponses = spec.defaultResponses
+	return &file
+}
+
+// pkg returns the import path of the package of the file parsed, or the name
+// of the package when the path of the file is unknown
+func (spec *openAPI) pkg(f *ast.File) string {
+	if spec.pkgPath != "" {
+		return spec.pkgPath
+	}
+	return f.Name.Name
+}
+
+// parseFiles parses the files with a pool of spec.jobs workers, the results
+// are in the order of the files
+func (spec *openAPI) parseFiles(files []string) []fileResult {
+	jobs := spec.jobs
+	if jobs < 1 {
+		jobs = runtime.NumCPU()
+	}
+
+	results := make([]fileResult, len(files))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = spec.parseGoFile(files[i])
+			}
+		}()
+	}
+	for i := range files {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+// parseGoFile parses a go file in its own document, the generated files are
+// skipped. The result is read from the cache when the file is unchanged.
+func (spec *openAPI) parseGoFile(path string) fileResult {
+	result := fileResult{path: path}
+
+	data, err := ioutil.ReadFile(path)
+	pkgPath := packagePath(path)
+	var key string
+	if err == nil && spec.cacheDir != "" {
+		key = spec.cacheKey(path, pkgPath, data)
+		if cached, ok := spec.loadCached(path, key); ok {
+			return cached
+		}
+	}
+
+	var astFile *ast.File
+	if err == nil {
+		astFile, err = parseSource(spec.fset, path, data)
+	}
+	if err != nil {
+		logrus.WithError(err).WithField("file", path).Error("Unable to parse file")
+		result.errs = append(result.errs, &BuildError{
+			Err:     err,
+			Content: path,
+			Message: "unable to parse file",
+		})
+		return result
+	}
+	if isGenerated(astFile) {
+		logrus.WithField("file", path).Debug("Skipping generated file")
+		spec.storeCached(key, result)
+		return result
+	}
+
+	file := spec.fileSpec()
+	file.pkgPath = pkgPath
+	result.spec = file
+	result.errs = append(result.errs, file.parseInfos(astFile)...)
+	result.errs = append(result.errs, file.parseSchemas(astFile)...)
+	result.errs = append(result.errs, file.parseComponents(astFile)...)
+	result.errs = append(result.errs, file.parseGlobals(astFile)...)
+	result.errs = append(result.errs, file.parseRootExtensions(astFile)...)
+	result.errs = append(result.errs, file.parsePaths(astFile)...)
+	result.errs = append(result.errs, file.parseWebhooks(astFile)...)
+	file.qualifyGenericRefs(astFile)
+	spec.storeCached(key, result)
+	return result
+}
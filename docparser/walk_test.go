@@ -0,0 +1,192 @@
+package docparser
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func parseWithJobs(t testing.TB, jobs int, paths ...string) []byte {
+	spec := NewOpenAPI()
+	spec.SetJobs(jobs)
+	spec.Parse(paths, nil, "vendor", false)
+	d, err := yaml.Marshal(&spec)
+	assert.NoError(t, err)
+	return d
+}
+
+func TestParseJobs(t *testing.T) {
+	// yaml.v2 doesn't always sort the status codes the same way, the
+	// documents are compared once unmarshalled
+	var expected interface{}
+	assert.NoError(t, yaml.Unmarshal(parseWithJobs(t, 1, "datatest"), &expected))
+	for i := 0; i < 5; i++ {
+		var actual interface{}
+		assert.NoError(t, yaml.Unmarshal(parseWithJobs(t, 8, "datatest"), &actual))
+		assert.Equal(t, expected, actual)
+	}
+}
+
+func TestParseFilesConflicts(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{}
+	for i := 0; i < 20; i++ {
+		files[filepath.Join(dir, fmt.Sprintf("pets%02d.go", i))] = fmt.Sprintf(`package api
+
+// @openapi:server
+//	url: https://api.example.com
+//	description: server %d
+
+// @openapi:path
+// /pets:
+//	get:
+//		description: "pets %d"
+//		responses:
+//			"200":
+//				description: "The pets"
+func Pets%d() {}
+`, i, i, i)
+	}
+	writeFiles(t, files)
+
+	for _, jobs := range []int{1, 4, 20} {
+		t.Run(fmt.Sprintf("%d jobs", jobs), func(t *testing.T) {
+			spec := NewOpenAPI()
+			spec.SetJobs(jobs)
+			failed := 0
+			for _, result := range spec.parseFiles([]string{
+				filepath.Join(dir, "pets00.go"),
+				filepath.Join(dir, "pets01.go"),
+				filepath.Join(dir, "pets02.go"),
+			}) {
+				failed += len(spec.mergeFile(result.spec))
+			}
+
+			// the first file wins
+			assert.Equal(t, 4, failed)
+			assert.Equal(t, "pets 0", spec.Paths["/pets"].Operations["get"].Description)
+			assert.Equal(t, []server{{URL: "https://api.example.com", Description: "server 0"}}, spec.Servers)
+		})
+	}
+}
+
+func TestParseDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, map[string]string{
+		filepath.Join(dir, "pets.go"): `package api
+
+import "net/http"
+
+// @openapi:path
+// /pets:
+//	get:
+//		responses:
+//			"200":
+//				description: "The pets"
+func Pets(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusTeapot)
+}
+`,
+		filepath.Join(dir, "broken.go"): `package api
+
+// @openapi:path
+// /broken:
+//	get: [
+func Broken() {}
+`,
+	})
+
+	spec := NewOpenAPI()
+	spec.Parse([]string{dir}, nil, "vendor", false)
+	assert.Equal(t, []string{filepath.Join(dir, "broken.go"), filepath.Join(dir, "pets.go")}, spec.Files())
+	assert.Len(t, spec.Errors(), 1)
+	assert.Equal(t, []string{
+		"get /pets: handler returns an undocumented status code 418",
+		"get /pets: handler never returns the documented status code 200",
+	}, spec.Warnings())
+}
+
+func TestParseExitError(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, map[string]string{
+		filepath.Join(dir, "broken.go"): `package api
+
+// @openapi:path
+// /broken:
+//	get: [
+func Broken() {}
+`,
+	})
+
+	// the errors are returned rather than exiting the process
+	spec := NewOpenAPI()
+	assert.EqualError(t, spec.Parse([]string{dir}, nil, "vendor", true), "1 errors found while parsing")
+	assert.Empty(t, spec.Paths)
+	spec = NewOpenAPI()
+	assert.NoError(t, spec.Parse([]string{dir}, nil, "vendor", false))
+}
+
+func TestDirs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, map[string]string{
+		filepath.Join(dir, "go.mod"):                 "module example.com/pets\n",
+		filepath.Join(dir, "api", "pets.go"):         "package api\n",
+		filepath.Join(dir, "api", "v2", "README.md"): "",
+		filepath.Join(dir, "api", "gen", "gen.go"):   "package gen\n",
+		filepath.Join(dir, "api", "testdata", "a"):   "",
+		filepath.Join(dir, "api", ".git", "HEAD"):    "",
+	})
+
+	spec := NewOpenAPI()
+	assert.NoError(t, spec.SetExclude([]string{"gen"}))
+	// the folders without go files are watched as well
+	assert.Equal(t, []string{
+		dir,
+		filepath.Join(dir, "api"),
+		filepath.Join(dir, "api", "v2"),
+	}, spec.Dirs([]string{filepath.Join(dir, "api")}))
+}
+
+func BenchmarkParse(b *testing.B) {
+	dir := b.TempDir()
+	files := map[string]string{}
+	for i := 0; i < 200; i++ {
+		files[filepath.Join(dir, fmt.Sprintf("pkg%d", i%10), fmt.Sprintf("pets%d.go", i))] = fmt.Sprintf(`package api
+
+// Pet%d is a pet
+// @openapi:schema
+type Pet%d struct {
+	ID       string            `+"`json:\"id\" validate:\"required\"`"+`
+	Name     string            `+"`json:\"name\"`"+`
+	Tags     []string          `+"`json:\"tags\"`"+`
+	Metadata map[string]string `+"`json:\"metadata\"`"+`
+}
+
+// @openapi:path
+// /pets%d/{id}:
+//	get:
+//		description: "Returns a pet"
+//		responses:
+//			"200":
+//				description: "The pet"
+//				content:
+//					application/json:
+//						schema:
+//							$ref: "#/components/schemas/Pet%d"
+func GetPet%d() {}
+`, i, i, i, i, i)
+	}
+	writeFiles(b, files)
+
+	for _, jobs := range []int{1, runtime.NumCPU()} {
+		b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				parseWithJobs(b, jobs, dir)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package docparser
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// @openapi:webhook newPet
+// post:
+var regexpWebhook = regexp.MustCompile(`@openapi:webhook ([\w.-]+)\n([^@]*)$`)
+
+// parseWebhooks parses the webhooks of the document, each one is a path item
+func (spec *openAPI) parseWebhooks(f *ast.File) (errs []error) {
+	for _, s := range f.Comments {
+		t := s.Text()
+		a := regexpWebhook.FindStringSubmatch(t)
+		if len(a) == 0 {
+			continue
+		}
+		name := a[1]
+
+		// Replacing tab with spaces
+		content := tab.ReplaceAllString(a[2], "  ")
+
+		p := path{}
+		if err := yaml.Unmarshal([]byte(content), &p); err != nil {
+			logrus.
+				WithError(err).
+				WithField("content", content).
+				Error("Unable to unmarshal webhook")
+			errs = append(errs, &BuildError{
+				Err:     err,
+				Content: content,
+				Message: "unable to unmarshal webhook",
+			})
+			continue
+		}
+
+		errs = append(errs, spec.addWebhook(name, p)...)
+		logrus.WithField("name", name).Info("Parsing webhook")
+	}
+	return
+}
+
+// addWebhook adds a webhook, the operations of a webhook which already exists
+// are added to it
+func (spec *openAPI) addWebhook(name string, p path) (errs []error) {
+	if spec.Webhooks == nil {
+		spec.Webhooks = make(paths)
+	}
+	current, ok := spec.Webhooks[name]
+	if !ok {
+		spec.Webhooks[name] = p
+		return nil
+	}
+
+	current.mergeFields(p)
+	if current.Operations == nil && len(p.Operations) > 0 {
+		current.Operations = make(map[string]operation)
+	}
+	for _, verb := range verbs {
+		op, ok := p.Operations[verb]
+		if !ok {
+			continue
+		}
+		if _, exists := current.Operations[verb]; exists {
+			logrus.
+				WithField("name", name).
+				WithField("verb", verb).
+				Error("Verb for this webhook already exists")
+			errs = append(errs, &BuildError{
+				Err:     errors.New("verb for this webhook already exists"),
+				Content: fmt.Sprintf("webhook: %s, verb: %s", name, verb),
+			})
+			continue
+		}
+		current.Operations[verb] = op
+	}
+	spec.Webhooks[name] = current
+	return errs
+}
+
+// goTypeRef turns a schema ref written as a Go type, i.e. PetCreated or
+// events.PetCreated, into the ref of its component schema. The other refs are
+// returned as they are.
+func goTypeRef(ref string) string {
+	if ref == "" || strings.ContainsAny(ref, "#/") {
+		return ref
+	}
+	for _, ext := range []string{".yaml", ".yml", ".json"} {
+		if strings.HasSuffix(ref, ext) {
+			return ref
+		}
+	}
+
+	expr, err := parser.ParseExpr(ref)
+	if err != nil {
+		return ref
+	}
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return schemaRefPrefix + e.Name
+	case *ast.SelectorExpr:
+		// the schemas of the types are named without their package
+		return schemaRefPrefix + e.Sel.Name
+	case *ast.IndexExpr, *ast.IndexListExpr:
+	default:
+		return ref
+	}
+
+	name, err := typeExprString(expr)
+	if err != nil {
+		return ref
+	}
+	return schemaRefPrefix + name
+}
+
+// resolveTypeRefs rewrites the schema refs written as Go types in the paths,
+// the webhooks and the components
+func (spec *openAPI) resolveTypeRefs() {
+	spec.mapPathsRefs(goTypeRef)
+	spec.mapComponentsRefs(goTypeRef)
+}
@@ -0,0 +1,167 @@
+package docparser
+
+import (
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const webhooksSource = `package test
+
+// PetCreated is sent when a pet is created
+// @openapi:schema:PetCreatedEvent
+type PetCreated struct {
+	ID string ` + "`json:\"id\"`" + `
+}
+
+// @openapi:callback PetStatus
+//	'{$request.body#/callbackUrl}':
+//		post:
+//			requestBody:
+//				content:
+//					application/json:
+//						schema:
+//							$ref: PetCreated
+//			responses:
+//				"200":
+//					description: Received
+
+// @openapi:webhook newPet
+//	post:
+//		summary: A pet was created
+//		requestBody:
+//			content:
+//				application/json:
+//					schema:
+//						$ref: events.PetCreated
+//		responses:
+//			"200":
+//				description: Received
+func SendPetCreated() {}
+
+// @openapi:path
+// /pets:
+//	post:
+//		callbacks:
+//			onCreated:
+//				'{$request.body#/callbackUrl}':
+//					post:
+//						requestBody:
+//							content:
+//								application/json:
+//									schema:
+//										$ref: PetCreated
+//						responses:
+//							"200":
+//								description: Received
+//		responses:
+//			"201":
+//				description: Created
+func CreatePet() {}
+`
+
+func TestParseWebhooks(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "", webhooksSource, parser.ParseComments)
+	assert.NoError(t, err)
+
+	spec := NewOpenAPI()
+	assert.Empty(t, spec.parseSchemas(f))
+	assert.Empty(t, spec.parseComponents(f))
+	assert.Empty(t, spec.parsePaths(f))
+	assert.Empty(t, spec.parseWebhooks(f))
+	spec.resolveTypeRefs()
+	spec.composeSpecSchemas()
+
+	const ref = "#/components/schemas/PetCreatedEvent"
+	webhook := spec.Webhooks["newPet"].Operations["post"]
+	assert.Equal(t, "A pet was created", webhook.Summary)
+	assert.Equal(t, ref, webhook.RequestBody.Content["application/json"].Schema.Ref)
+
+	callback := spec.Paths["/pets"].Operations["post"].Callbacks["onCreated"]["{$request.body#/callbackUrl}"]
+	assert.Equal(t, ref, callback.Operations["post"].RequestBody.Content["application/json"].Schema.Ref)
+
+	component := spec.Components.Callbacks["PetStatus"]["{$request.body#/callbackUrl}"]
+	assert.Equal(t, ref, component.Operations["post"].RequestBody.Content["application/json"].Schema.Ref)
+
+	b, err := yaml.Marshal(spec.Webhooks)
+	assert.NoError(t, err)
+	assert.Equal(t, `newPet:
+  post:
+    summary: A pet was created
+    responses:
+      "200":
+        description: Received
+    requestBody:
+      content:
+        application/json:
+          schema:
+            $ref: '#/components/schemas/PetCreatedEvent'
+`, string(b))
+}
+
+func TestParseWebhooksDuplicated(t *testing.T) {
+	src := `package test
+
+// @openapi:webhook newPet
+//	post:
+//		responses:
+//			"200":
+//				description: Received
+
+// @openapi:webhook newPet
+//	put:
+//		responses:
+//			"200":
+//				description: Received
+
+// @openapi:webhook newPet
+//	post:
+//		responses:
+//			"200":
+//				description: Received
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+	assert.NoError(t, err)
+
+	spec := NewOpenAPI()
+	assert.Len(t, spec.parseWebhooks(f), 1)
+	assert.Len(t, spec.Webhooks["newPet"].Operations, 2)
+}
+
+func TestWebhooksVersion(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, map[string]string{filepath.Join(dir, "pets.go"): webhooksSource})
+
+	spec := NewOpenAPI()
+	spec.Parse([]string{dir}, nil, "vendor", false)
+	assert.Empty(t, spec.Errors())
+	assert.Equal(t, "3.1.0", spec.Openapi)
+
+	merged := NewOpenAPI()
+	assert.NoError(t, merged.Merge(NewOpenAPI()))
+	assert.Equal(t, "3.0.0", merged.Openapi)
+	assert.NoError(t, merged.Merge(spec))
+	assert.Equal(t, "3.1.0", merged.Openapi)
+}
+
+func TestGoTypeRef(t *testing.T) {
+	testCases := map[string]string{
+		"":                            "",
+		"Pet":                         "#/components/schemas/Pet",
+		"events.PetCreated":           "#/components/schemas/PetCreated",
+		"Page[events.PetCreated]":     "#/components/schemas/Page[events.PetCreated]",
+		"#/components/schemas/Pet":    "#/components/schemas/Pet",
+		"pet.yaml":                    "pet.yaml",
+		"definitions.json#/Pet":       "definitions.json#/Pet",
+		"https://example.com/pet.yml": "https://example.com/pet.yml",
+		"[]Pet":                       "[]Pet",
+	}
+
+	for ref, expected := range testCases {
+		assert.Equal(t, expected, goTypeRef(ref), ref)
+	}
+}